@@ -20,13 +20,14 @@ var (
 // NewApp creates and configures the cli.Command instance.
 func NewApp() *cli.Command {
 	cmd := &cli.Command{
-		Name:    "tfsort",
-		Usage:   "A fast, opinionated sorter for Terraform configuration files.",
-		Version: fmt.Sprintf("%s, commit %s, built at %s", version, commit, date),
-		Flags:   commands.GetFlags(),   // Get flags from the commands package
-		Action:  commands.TfsortAction, // Use the action from the commands package
+		Name:     "tfsort",
+		Usage:    "A fast, opinionated sorter for Terraform configuration files.",
+		Version:  fmt.Sprintf("%s, commit %s, built at %s", version, commit, date),
+		Flags:    commands.GetFlags(),   // Get flags from the commands package
+		Action:   commands.TfsortAction, // Use the action from the commands package
+		Commands: commands.Subcommands(),
 		// Hide the default 'help' command generated by urfave/cli
-		// because we are not using subcommands for the main functionality.
+		// because sorting files is the main functionality, not a subcommand.
 		// The default help message (triggered by -h or --help) is still shown.
 		HideHelpCommand: true,
 	}
@@ -36,7 +37,7 @@ func NewApp() *cli.Command {
 func main() {
 	// Create the app using NewApp and run it.
 	// If Run returns an error, log it fatally.
-	if err := NewApp().Run(context.Background(), os.Args); err != nil {
+	if err := NewApp().Run(context.Background(), commands.NormalizeArgs(os.Args)); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// writeExplanation writes the change log of --explain for the file at path:
+// every block sorting moved with the key it was sorted by, and every list it
+// sorted or skipped. explanation is nil for a file in JSON syntax, which is
+// sorted without recording one; a change to it is described as a whole.
+func writeExplanation(w io.Writer, path string, changed bool, explanation *sorter.Explanation) error {
+	var b strings.Builder
+	status := "unchanged"
+	if changed {
+		status = "changed"
+	}
+	fmt.Fprintf(&b, "%s: %s\n", path, status)
+	if explanation == nil {
+		if changed {
+			b.WriteString("  file sorted; moved blocks and sorted lists are not recorded for JSON syntax\n")
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	for _, move := range explanation.Blocks {
+		fmt.Fprintf(&b, "  block %s moved", move.Name)
+		switch {
+		case move.Before != "":
+			fmt.Fprintf(&b, " before %s", move.Before)
+		case move.After != "":
+			fmt.Fprintf(&b, " after %s", move.After)
+		}
+		if move.GluedTo != "" {
+			fmt.Fprintf(&b, " with %s, which a directive glues it to\n", move.GluedTo)
+			continue
+		}
+		fmt.Fprintf(&b, "; sort key: type rank %d", move.Rank)
+		if move.Required != "" {
+			fmt.Fprintf(&b, ", %s variable", move.Required)
+		}
+		if len(move.Labels) > 0 {
+			fmt.Fprintf(&b, ", labels %s", quoteAll(move.Labels))
+		}
+		b.WriteString("\n")
+	}
+
+	sortedLists := 0
+	for _, list := range explanation.Lists {
+		if list.Skipped != "" {
+			fmt.Fprintf(&b, "  list %s skipped: %s\n", list.Path, list.Skipped)
+			continue
+		}
+		sortedLists++
+		fmt.Fprintf(&b, "  list %s sorted: [%s] -> [%s]\n", list.Path, strings.Join(list.Before, ", "), strings.Join(list.After, ", "))
+	}
+
+	if changed && len(explanation.Blocks) == 0 && sortedLists == 0 {
+		b.WriteString("  no blocks moved and no lists sorted; other rules, such as attribute or locals ordering, changed the file\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quoteAll quotes strs and joins them with spaces.
+func quoteAll(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, s := range strs {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, " ")
+}
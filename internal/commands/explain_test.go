@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/sorter"
+	"github.com/urfave/cli/v3"
+)
+
+func TestWriteExplanation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		changed     bool
+		explanation *sorter.Explanation
+		want        string
+	}{
+		{
+			name:    "blocks and lists",
+			changed: true,
+			explanation: &sorter.Explanation{
+				Blocks: []sorter.BlockMove{
+					{Address: "variable.x", Name: "variable.x", Before: "resource.aws_iam_role.a", Rank: 3, Labels: []string{"x"}, Required: "required"},
+					{Address: "resource.aws_iam_role.a", Name: "resource.aws_iam_role.a", Before: "resource.aws_s3_bucket.b", Rank: 7, Labels: []string{"aws_iam_role", "a"}},
+					{Address: "output.a", Name: "output.a", After: "resource.aws_s3_bucket.b", Rank: 8},
+					{Address: "output.b", Name: "output.b", Before: "resource.c.c", Rank: 8, GluedTo: "resource.a.a"},
+				},
+				Lists: []sorter.ListChange{
+					{Path: "module.vpc.azs", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}},
+					{Path: "module.vpc.subnets", Skipped: sorter.SkipIgnoreDirective},
+				},
+			},
+			want: `main.tf: changed
+  block variable.x moved before resource.aws_iam_role.a; sort key: type rank 3, required variable, labels "x"
+  block resource.aws_iam_role.a moved before resource.aws_s3_bucket.b; sort key: type rank 7, labels "aws_iam_role" "a"
+  block output.a moved after resource.aws_s3_bucket.b; sort key: type rank 8
+  block output.b moved before resource.c.c with resource.a.a, which a directive glues it to
+  list module.vpc.azs sorted: ["b", "a"] -> ["a", "b"]
+  list module.vpc.subnets skipped: tfsort:ignore directive
+`,
+		},
+		{
+			name:    "blocks without labels",
+			changed: true,
+			explanation: &sorter.Explanation{
+				Blocks: []sorter.BlockMove{
+					{Address: "locals", Name: "locals#2", Before: "locals#1", Rank: 5, Occurrence: 1},
+				},
+			},
+			want: `main.tf: changed
+  block locals#2 moved before locals#1; sort key: type rank 5
+`,
+		},
+		{
+			name:    "other changes",
+			changed: true,
+			explanation: &sorter.Explanation{
+				Lists: []sorter.ListChange{{Path: "locals.one", Before: []string{"1"}, Skipped: sorter.SkipTooFewElements}},
+			},
+			want: `main.tf: changed
+  list locals.one skipped: fewer than two elements
+  no blocks moved and no lists sorted; other rules, such as attribute or locals ordering, changed the file
+`,
+		},
+		{
+			name:        "unchanged",
+			explanation: &sorter.Explanation{},
+			want:        "main.tf: unchanged\n",
+		},
+		{
+			name:    "JSON syntax",
+			changed: true,
+			want:    "main.tf: changed\n  file sorted; moved blocks and sorted lists are not recorded for JSON syntax\n",
+		},
+		{
+			name: "unchanged JSON syntax",
+			want: "main.tf: unchanged\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeExplanation(&b, "main.tf", tc.changed, tc.explanation); err != nil {
+				t.Fatalf("writeExplanation() unexpected error = %v", err)
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("writeExplanation() =\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOutputsAgree(t *testing.T) {
+	tmpDir, cleanup := setupTestFiles(t, map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "b" {}
+output "o" {}
+resource "aws_iam_role" "a" {
+  tags = ["b", "a"]
+}
+variable "x" {}
+locals {}
+module "m" {
+  source = "./m"
+  azs    = toset(["b", "c", "a"])
+}
+`,
+	})
+	defer cleanup()
+	t.Chdir(tmpDir)
+	originalIsInputFromPipe := isInputFromPipe
+	defer func() { isInputFromPipe = originalIsInputFromPipe }()
+	isInputFromPipe = func() bool { return false }
+
+	run := func(command *cli.Command, args ...string) (stdout, stderr string) {
+		command.ExitErrHandler = func(ctx context.Context, cmd *cli.Command, err error) {
+			// Prevent os.Exit during tests
+		}
+		stderr = captureStderr(t, func() {
+			stdout = captureOutput(t, func() {
+				_ = command.Run(context.Background(), append([]string{command.Name}, args...))
+			})
+		})
+		return stdout, stderr
+	}
+
+	// Moved blocks and sorted lists as counted by the JSON report, which
+	// includes the summary statistics.
+	jsonOut, _ := run(&cli.Command{Name: "tfsort", Flags: GetFlags(), Action: TfsortAction}, "--format", "json", "--summary", "main.tf")
+	var got report.Report
+	if err := json.Unmarshal([]byte(jsonOut), &got); err != nil || len(got.Files) != 1 || got.Summary == nil {
+		t.Fatalf("stdout is not a JSON report of one file with a summary: %v\n%s", err, jsonOut)
+	}
+	var jsonBlocks, jsonLists int
+	for _, change := range got.Files[0].Changes {
+		switch change.Kind {
+		case report.ChangeBlockMoved:
+			jsonBlocks++
+		case report.ChangeListSorted:
+			jsonLists++
+		}
+	}
+
+	// As counted by lint.
+	lintOut, _ := run(LintCommand(), "main.tf")
+	lintBlocks := strings.Count(lintOut, "[block-order]") + strings.Count(lintOut, "[type-name-order]")
+	lintLists := strings.Count(lintOut, "[list-order]")
+
+	// As counted by --explain.
+	_, explainOut := run(&cli.Command{Name: "tfsort", Flags: GetFlags(), Action: TfsortAction}, "--explain", "--dry-run", "main.tf")
+	explainBlocks := strings.Count(explainOut, "\n  block ")
+	explainLists := strings.Count(explainOut, " sorted: ")
+
+	if jsonBlocks < 2 || jsonLists < 2 {
+		t.Fatalf("JSON report has %d moved blocks and %d sorted lists, want at least 2 of each", jsonBlocks, jsonLists)
+	}
+	if lintBlocks != jsonBlocks || lintLists != jsonLists {
+		t.Errorf("lint reports %d moved blocks and %d sorted lists, JSON report %d and %d\n%s", lintBlocks, lintLists, jsonBlocks, jsonLists, lintOut)
+	}
+	if explainBlocks != jsonBlocks || explainLists != jsonLists {
+		t.Errorf("--explain reports %d moved blocks and %d sorted lists, JSON report %d and %d\n%s", explainBlocks, explainLists, jsonBlocks, jsonLists, explainOut)
+	}
+	if got.Summary.BlocksReordered != jsonBlocks || got.Summary.ListsSorted != jsonLists {
+		t.Errorf("summary counts %d reordered blocks and %d sorted lists, JSON report %d and %d", got.Summary.BlocksReordered, got.Summary.ListsSorted, jsonBlocks, jsonLists)
+	}
+}
+
+// captureStderr returns what actionFunc writes to os.Stderr. Log output is not
+// captured, as the logger keeps the original stderr.
+func captureStderr(t *testing.T, actionFunc func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe failed: %v", err)
+	}
+	originalStderr := os.Stderr
+	os.Stderr = w
+	defer func() {
+		os.Stderr = originalStderr
+	}()
+
+	actionFunc()
+
+	if err := w.Close(); err != nil {
+		t.Logf("Warning: failed to close writer pipe: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Logf("Warning: failed to copy from reader pipe to buffer: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExplainJSONSyntax(t *testing.T) {
+	tmpDir, cleanup := setupTestFiles(t, map[string]string{
+		"main.tf.json":       "{\"variable\": {\"b\": {}, \"a\": {}}}\n",
+		"sorted.tfvars.json": "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+	})
+	defer cleanup()
+	t.Chdir(tmpDir)
+	originalIsInputFromPipe := isInputFromPipe
+	defer func() { isInputFromPipe = originalIsInputFromPipe }()
+	isInputFromPipe = func() bool { return false }
+
+	command := &cli.Command{Name: "tfsort", Flags: GetFlags(), Action: TfsortAction}
+	command.ExitErrHandler = func(ctx context.Context, cmd *cli.Command, err error) {}
+	got := captureStderr(t, func() {
+		captureOutput(t, func() {
+			_ = command.Run(context.Background(), []string{"tfsort", "--explain", "--dry-run", "main.tf.json", "sorted.tfvars.json"})
+		})
+	})
+	want := "main.tf.json: changed\n  file sorted; moved blocks and sorted lists are not recorded for JSON syntax\nsorted.tfvars.json: unchanged\n"
+	if got != want {
+		t.Errorf("--explain wrote\n%s\nwant:\n%s", got, want)
+	}
+}
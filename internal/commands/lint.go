@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/sorter"
+	"github.com/urfave/cli/v3"
+)
+
+// Lint severities.
+const (
+	severityOff   = "off"
+	severityWarn  = "warn"
+	severityError = "error"
+)
+
+// lintRules maps the rules whose severity can be configured to their default
+// severity. Parse and processing errors are always errors.
+var lintRules = map[string]string{
+	report.RuleBlockOrder:    severityError,
+	report.RuleTypeNameOrder: severityError,
+	report.RuleListOrder:     severityError,
+	report.RuleFileOrder:     severityError,
+}
+
+// lintFlags defines the CLI flags for the lint command.
+var lintFlags = append([]cli.Flag{
+	&cli.BoolFlag{
+		Name:    "recursive",
+		Aliases: []string{"r"},
+		Usage:   "Walk directories recursively and lint all supported files",
+	},
+	&cli.BoolFlag{
+		Name:  "follow-modules",
+		Usage: "Lint the directories given as root modules and the local modules they call, transitively, instead of walking directories",
+	},
+	&cli.StringSliceFlag{
+		Name:  "rule",
+		Usage: "Set the severity of a rule with `RULE=SEVERITY`, where SEVERITY is off, warn or error; overrides the config file and can be repeated",
+	},
+}, sortFlags...)
+
+// LintCommand returns the command that reports every place where files are
+// not sorted, without rewriting them.
+func LintCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "lint",
+		Usage:     "Report every violation of the sort order with its rule, position and severity, without rewriting files",
+		ArgsUsage: "[PATH...]",
+		Flags:     lintFlags,
+		Action:    LintAction,
+	}
+}
+
+// LintAction sorts every input and prints a line for each finding, such as a
+// block out of order or an unsorted list, at the position of the original file
+// it concerns. It exits with status 1 if a finding has the error severity, and
+// with status 2 if a file cannot be processed.
+func LintAction(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+
+	sortOpts, profiles, err := sortOptionsFromFlags(cmd)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	cfg, err := loadConfig(cmd.String("config"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	severities, err := lintSeverities(cfg.LintRules(), cmd.StringSlice("rule"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	// Lists and labels are left as they are when their rule is off, so that
+	// sorting them does not surface as a file-order finding.
+	if severities[report.RuleListOrder] == severityOff {
+		sortOpts.SortList = false
+	}
+	if severities[report.RuleTypeNameOrder] == severityOff {
+		sortOpts.SortTypeName = false
+	}
+
+	recursive := cmd.Bool("recursive")
+	followModules := cmd.Bool("follow-modules")
+	if recursive && followModules {
+		return cli.Exit("Error: --recursive and --follow-modules cannot be used together.", 2)
+	}
+
+	sources, _, err := collectInputs(args, recursive, followModules, profiles)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: failed to process inputs: %v", err), 2)
+	}
+	if len(sources) == 0 {
+		if len(args) > 0 {
+			log.Println("No input files found.")
+		} else if !isInputFromPipe() {
+			log.Println("No input files specified and no data piped from stdin.")
+		}
+		return nil
+	}
+
+	hasErrors := false
+	violations := 0
+	for _, source := range sources {
+		originalBytes := make([]byte, len(source.Content))
+		copy(originalBytes, source.Content)
+
+		file := report.File{Path: source.Path, Status: report.StatusUnchanged}
+		opts := sortOpts
+		opts.Explain = &sorter.Explanation{}
+		sortedBytes, _, err := sortSource(source, opts, profiles)
+		if err != nil {
+			hasErrors = true
+			file.Status = report.StatusError
+			file.Error = err.Error()
+			var diagsErr *diagnosticsError
+			if errors.As(err, &diagsErr) {
+				file.Diagnostics = report.Diagnostics(diagsErr.Diagnostics)
+			}
+		} else if !bytes.Equal(originalBytes, sortedBytes) {
+			file.Status = report.StatusChanged
+		}
+
+		findings := report.Findings(file, originalBytes, sortedBytes, opts.Explain)
+		sort.SliceStable(findings, func(i, j int) bool {
+			return startLine(findings[i]) < startLine(findings[j])
+		})
+		for _, f := range findings {
+			switch severities[f.Rule] {
+			case severityOff:
+				continue
+			case severityWarn:
+				f.Level = report.LevelWarning
+			case severityError:
+				f.Level = report.LevelError
+				violations++
+			}
+			fmt.Println(lintLine(source.Path, f))
+		}
+	}
+
+	if hasErrors {
+		return cli.Exit("Encountered errors during processing.", 2)
+	}
+	if violations > 0 {
+		return cli.Exit(fmt.Sprintf("Found %d lint errors.", violations), 1)
+	}
+	return nil
+}
+
+// lintSeverities returns the severity of every configurable rule: its default,
+// overridden by the rules of the config file, then by the RULE=SEVERITY values
+// of the --rule flag.
+func lintSeverities(configured map[string]string, overrides []string) (map[string]string, error) {
+	severities := make(map[string]string, len(lintRules))
+	for rule, severity := range lintRules {
+		severities[rule] = severity
+	}
+	set := func(rule, severity string) error {
+		if _, ok := lintRules[rule]; !ok {
+			var names []string
+			for name := range lintRules {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("invalid lint rule %q (want one of %s)", rule, strings.Join(names, ", "))
+		}
+		switch severity {
+		case severityOff, severityWarn, severityError:
+			severities[rule] = severity
+			return nil
+		}
+		return fmt.Errorf("invalid severity %q for rule %s (want %q, %q or %q)", severity, rule, severityOff, severityWarn, severityError)
+	}
+
+	for rule, severity := range configured {
+		if err := set(rule, severity); err != nil {
+			return nil, err
+		}
+	}
+	for _, override := range overrides {
+		rule, severity, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule setting %q (want RULE=SEVERITY)", override)
+		}
+		if err := set(rule, severity); err != nil {
+			return nil, err
+		}
+	}
+	return severities, nil
+}
+
+// lintLine formats a finding as "path:line:column: level: message [rule]", or
+// without the line and column if the finding concerns the whole file.
+func lintLine(path string, f report.Finding) string {
+	location := path
+	if f.Range != nil {
+		location = fmt.Sprintf("%s:%d:%d", path, f.Range.Start.Line, f.Range.Start.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, f.Level, f.Message, f.Rule)
+}
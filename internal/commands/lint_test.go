@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+func TestLintAction(t *testing.T) {
+	unsorted := `resource "aws_s3_bucket" "b" {}
+resource "aws_iam_role" "a" {}
+resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = ["b", "a"]
+  }
+}
+variable "x" {}
+`
+	sorted := "variable \"x\" {}\n\nresource \"aws_iam_role\" \"a\" {}\n"
+
+	testCases := []struct {
+		name                string
+		files               map[string]string
+		args                []string
+		wantStdout          string
+		wantExitCode        int
+		wantErrMsgSubstring string
+	}{
+		{
+			name:  "violations are errors by default",
+			files: map[string]string{"main.tf": unsorted},
+			args:  []string{"main.tf"},
+			wantStdout: "main.tf:2:1: error: resource.aws_iam_role.a is out of order among the resource blocks; it belongs before resource.aws_s3_bucket.b. [type-name-order]\n" +
+				"main.tf:5:19: error: The list in resource.aws_security_group.web.ingress.cidr_blocks is not sorted. [list-order]\n" +
+				"main.tf:8:1: error: variable.x is out of block type order; it belongs before resource.aws_iam_role.a. [block-order]\n",
+			wantExitCode:        1,
+			wantErrMsgSubstring: "Found 3 lint errors.",
+		},
+		{
+			name:  "severities from flags",
+			files: map[string]string{"main.tf": unsorted},
+			args:  []string{"--rule", "list-order=warn", "--rule", "block-order=off", "--rule", "type-name-order=warn", "main.tf"},
+			wantStdout: "main.tf:2:1: warning: resource.aws_iam_role.a is out of order among the resource blocks; it belongs before resource.aws_s3_bucket.b. [type-name-order]\n" +
+				"main.tf:5:19: warning: The list in resource.aws_security_group.web.ingress.cidr_blocks is not sorted. [list-order]\n",
+		},
+		{
+			name: "severities from config file",
+			files: map[string]string{
+				"main.tf":     unsorted,
+				".tfsort.hcl": "lint {\n  rules = {\n    \"type-name-order\" = \"off\"\n    \"list-order\"      = \"off\"\n  }\n}\n",
+			},
+			args:         []string{"main.tf"},
+			wantStdout:   "main.tf:8:1: error: variable.x is out of block type order; it belongs before resource.aws_s3_bucket.b. [block-order]\n",
+			wantExitCode: 1,
+		},
+		{
+			name: "flags override config file",
+			files: map[string]string{
+				"main.tf":     "resource \"b\" \"b\" {}\nvariable \"x\" {}\n",
+				".tfsort.hcl": "lint {\n  rules = {\n    \"block-order\" = \"off\"\n  }\n}\n",
+			},
+			args:         []string{"--rule", "block-order=error", "main.tf"},
+			wantStdout:   "main.tf:2:1: error: variable.x is out of block type order; it belongs before resource.b.b. [block-order]\n",
+			wantExitCode: 1,
+		},
+		{
+			name:         "whole file finding",
+			files:        map[string]string{"terraform.tfvars": "b = 1\na = 2\n"},
+			args:         []string{"terraform.tfvars"},
+			wantStdout:   "terraform.tfvars: error: The file is not sorted. [file-order]\n",
+			wantExitCode: 1,
+		},
+		{
+			name:  "sorted file",
+			files: map[string]string{"main.tf": sorted},
+			args:  []string{"main.tf"},
+		},
+		{
+			name:                "parse error",
+			files:               map[string]string{"main.tf": sorted, "broken.tf": "variable \"a\" {\n"},
+			args:                []string{"-r", "."},
+			wantStdout:          "broken.tf:1:14: error: Unclosed configuration block: There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file. [parse-error]\n",
+			wantExitCode:        2,
+			wantErrMsgSubstring: "Encountered errors during processing.",
+		},
+		{
+			name:                "invalid rule",
+			files:               map[string]string{"main.tf": sorted},
+			args:                []string{"--rule", "parse-error=off", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid lint rule \"parse-error\" (want one of block-order, file-order, list-order, type-name-order)",
+		},
+		{
+			name:                "invalid severity",
+			files:               map[string]string{"main.tf": sorted},
+			args:                []string{"--rule", "list-order=info", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid severity \"info\" for rule list-order",
+		},
+		{
+			name:                "invalid rule setting",
+			files:               map[string]string{"main.tf": sorted},
+			args:                []string{"--rule", "list-order", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid rule setting \"list-order\" (want RULE=SEVERITY)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, cleanup := setupTestFiles(t, tc.files)
+			defer cleanup()
+			t.Chdir(tmpDir)
+
+			app := &cli.Command{
+				Name:     "tfsort-test-app",
+				Commands: []*cli.Command{LintCommand()},
+				ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+					// Prevent os.Exit during tests
+				},
+			}
+
+			var actionErr error
+			stdout := captureOutput(t, func() {
+				actionErr = app.Run(context.Background(), append([]string{app.Name, "lint"}, tc.args...))
+			})
+
+			exitCode := 0
+			if actionErr != nil {
+				exitCode = 2
+				if exitCoder, ok := actionErr.(cli.ExitCoder); ok {
+					exitCode = exitCoder.ExitCode()
+				}
+			}
+			if exitCode != tc.wantExitCode {
+				t.Errorf("exit code = %d, want %d (error: %v)", exitCode, tc.wantExitCode, actionErr)
+			}
+			if tc.wantErrMsgSubstring != "" && (actionErr == nil || !strings.Contains(actionErr.Error(), tc.wantErrMsgSubstring)) {
+				t.Errorf("error = %v, want error containing %q", actionErr, tc.wantErrMsgSubstring)
+			}
+			if stdout != tc.wantStdout {
+				t.Errorf("stdout =\n%s\nwant:\n%s", stdout, tc.wantStdout)
+			}
+		})
+	}
+}
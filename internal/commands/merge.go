@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tjun/tfsort/internal/sorter"
+	"github.com/urfave/cli/v3"
+)
+
+// defaultMergeOutput is the file a module is merged into unless --output is set.
+const defaultMergeOutput = "main.tf"
+
+// mergeFlags defines the CLI flags for the merge command.
+var mergeFlags = append([]cli.Flag{
+	&cli.BoolFlag{
+		Name:    "in-place",
+		Aliases: []string{"i"},
+		Usage:   "Write the merged file into the module directory and remove the merged files instead of printing to stdout",
+	},
+	&cli.StringFlag{
+		Name:  "output",
+		Value: defaultMergeOutput,
+		Usage: "Name of the merged `FILE` written with --in-place",
+	},
+	&cli.BoolFlag{
+		Name:  "origin-comments",
+		Usage: "Add a '# tfsort:source <file>' comment above each run of blocks that came from the same file",
+	},
+}, sortFlags...)
+
+// MergeCommand returns the command that combines the files of a module into
+// one sorted file.
+func MergeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "merge",
+		Usage:     "Combine the .tf files of a module directory into one sorted file",
+		ArgsUsage: "[DIR]",
+		Flags:     mergeFlags,
+		Action:    MergeAction,
+	}
+}
+
+// MergeAction combines the .tf files of a module directory into one file,
+// sorted with the normal block sorter. The result is printed to stdout, or
+// written to the output file with --in-place.
+func MergeAction(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) > 1 {
+		return cli.Exit("Error: merge takes a single module directory.", 2)
+	}
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	output := cmd.String("output")
+	if filepath.Base(output) != output || filepath.Ext(output) != ".tf" {
+		return cli.Exit(fmt.Sprintf("Error: invalid output file %q (want a .tf file name without a directory)", output), 2)
+	}
+	sortOpts, _, err := sortOptionsFromFlags(cmd)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+
+	files, err := readModuleFiles(dir)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	if len(files) == 0 {
+		log.Printf("No .tf files found in %s.", dir)
+		return nil
+	}
+
+	merged, err := sorter.MergeModule(files, cmd.Bool("origin-comments"), sortOpts)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+
+	if !cmd.Bool("in-place") {
+		if _, err := os.Stdout.Write(merged); err != nil {
+			return cli.Exit(fmt.Sprintf("Error writing to stdout: %v", err), 2)
+		}
+		return nil
+	}
+
+	outputPath := filepath.Join(dir, output)
+	if original, exists := files[output]; !exists || !bytes.Equal(original, merged) {
+		if err := os.WriteFile(outputPath, merged, 0644); err != nil {
+			return cli.Exit(fmt.Sprintf("Error writing file %s: %v", outputPath, err), 2)
+		}
+		log.Printf("Formatted %s", outputPath)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if name != output {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil {
+			log.Printf("Error removing file %s: %v", path, err)
+			failed = append(failed, name)
+			continue
+		}
+		log.Printf("Removed %s", path)
+	}
+	if len(failed) > 0 {
+		return cli.Exit(fmt.Sprintf("Encountered errors during processing: could not remove %s.", strings.Join(failed, ", ")), 2)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+func TestMergeAction(t *testing.T) {
+	module := map[string]string{
+		"main.tf":      "resource \"aws_s3_bucket\" \"logs\" {}\n",
+		"variables.tf": "variable \"name\" {}\n",
+		"override.tf":  "variable \"name\" {\n  default = \"x\"\n}\n",
+	}
+	merged := "variable \"name\" {}\n\nresource \"aws_s3_bucket\" \"logs\" {}\n"
+
+	testCases := []struct {
+		name                string
+		setup               map[string]string
+		args                []string
+		wantStdout          string
+		wantFiles           map[string]string // An empty string means the file must not exist
+		wantExitCode        int
+		wantErrMsgSubstring string
+	}{
+		{
+			name:       "prints the merged file",
+			setup:      module,
+			wantStdout: merged,
+			wantFiles:  module,
+		},
+		{
+			name:       "origin comments",
+			setup:      module,
+			args:       []string{"--origin-comments"},
+			wantStdout: "# tfsort:source variables.tf\nvariable \"name\" {}\n\n# tfsort:source main.tf\nresource \"aws_s3_bucket\" \"logs\" {}\n",
+		},
+		{
+			name:  "in-place writes the output file",
+			setup: module,
+			args:  []string{"-i", "--output=module.tf"},
+			wantFiles: map[string]string{
+				"module.tf":    merged,
+				"main.tf":      "",
+				"variables.tf": "",
+				"override.tf":  module["override.tf"],
+			},
+		},
+		{
+			name: "duplicate block",
+			setup: map[string]string{
+				"main.tf":      "variable \"name\" {}\n",
+				"variables.tf": "variable \"name\" {}\n",
+			},
+			args:                []string{"-i"},
+			wantFiles:           map[string]string{"main.tf": "variable \"name\" {}\n", "variables.tf": "variable \"name\" {}\n"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "variable.name is defined in main.tf and variables.tf",
+		},
+		{
+			name:                "invalid output file",
+			setup:               module,
+			args:                []string{"-i", "--output=../main.tf"},
+			wantFiles:           module,
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid output file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, cleanup := setupTestFiles(t, tc.setup)
+			defer cleanup()
+
+			app := &cli.Command{
+				Name:     "tfsort-test-app",
+				Commands: []*cli.Command{MergeCommand()},
+				ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+					// Prevent os.Exit during tests
+				},
+			}
+
+			var actionErr error
+			stdout := captureOutput(t, func() {
+				runArgs := append([]string{app.Name, "merge"}, tc.args...)
+				actionErr = app.Run(context.Background(), append(runArgs, tmpDir))
+			})
+
+			exitCode := 0
+			if actionErr != nil {
+				exitCode = 2
+				if exitCoder, ok := actionErr.(cli.ExitCoder); ok {
+					exitCode = exitCoder.ExitCode()
+				}
+			}
+			if exitCode != tc.wantExitCode {
+				t.Errorf("exit code = %d, want %d (error: %v)", exitCode, tc.wantExitCode, actionErr)
+			}
+			if tc.wantErrMsgSubstring != "" && (actionErr == nil || !strings.Contains(actionErr.Error(), tc.wantErrMsgSubstring)) {
+				t.Errorf("error = %v, want error containing %q", actionErr, tc.wantErrMsgSubstring)
+			}
+			if stdout != tc.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tc.wantStdout)
+			}
+
+			for name, want := range tc.wantFiles {
+				content, err := os.ReadFile(filepath.Join(tmpDir, name))
+				if want == "" {
+					if !os.IsNotExist(err) {
+						t.Errorf("file %s exists, want it removed", name)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("failed to read %s: %v", name, err)
+					continue
+				}
+				if string(content) != want {
+					t.Errorf("file %s content mismatch\nGot:\n%s\nWant:\n%s", name, content, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// Reporter writes the outcome of a run in a format read by other tools, in
+// place of the sorted content.
+type Reporter interface {
+	// Add records the outcome of a file. original and sorted are its content
+	// before and after sorting; sorted is nil if the file could not be sorted,
+	// and both are nil if it was skipped. explanation is the record of the
+	// changes sorting made, or nil if the file was not sorted.
+	Add(file report.File, original, sorted []byte, explanation *sorter.Explanation)
+	// Write writes the report of the recorded files to w.
+	Write(w io.Writer) error
+}
+
+// reporters are the reporters that can be chosen with --reporter.
+var reporters = map[string]func() Reporter{
+	"checkstyle": func() Reporter { return &checkstyleReporter{} },
+	"github":     func() Reporter { return &githubReporter{} },
+	"gitlab":     func() Reporter { return &gitlabReporter{} },
+	"junit":      func() Reporter { return &junitReporter{} },
+}
+
+// reporterNames returns the names of the reporters, sorted.
+func reporterNames() []string {
+	var names []string
+	for name := range reporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newReporter returns the reporter for an output format or a reporter name.
+// It returns nil for the text format, which prints the sorted content.
+func newReporter(format, name string) (Reporter, error) {
+	if name != "" {
+		if format != formatText {
+			return nil, fmt.Errorf("--reporter cannot be combined with --format %s", format)
+		}
+		newFunc, ok := reporters[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid reporter %q (want one of %s)", name, strings.Join(reporterNames(), ", "))
+		}
+		return newFunc(), nil
+	}
+	switch format {
+	case formatText:
+		return nil, nil
+	case formatJSON:
+		return &jsonReporter{}, nil
+	case formatSARIF:
+		return &report.SARIFLog{}, nil
+	}
+	return nil, fmt.Errorf("invalid format %q (want %q, %q or %q)", format, formatText, formatJSON, formatSARIF)
+}
+
+// jsonReporter writes the JSON report of --format json.
+type jsonReporter struct {
+	report report.Report
+}
+
+func (r *jsonReporter) Add(file report.File, original, sorted []byte, explanation *sorter.Explanation) {
+	// A changed file that could not be written is reported without changes.
+	if file.Status != report.StatusChanged {
+		file.Changes = nil
+	}
+	r.report.Add(file)
+}
+
+// SetSummary includes the summary statistics of the run in the report.
+func (r *jsonReporter) SetSummary(summary *report.Summary) {
+	r.report.Summary = summary
+}
+
+func (r *jsonReporter) Write(w io.Writer) error {
+	return r.report.Write(w)
+}
+
+// reportPath returns the path of a file as reporters write it: cleaned and
+// with forward slashes, so that CI systems can match it to a repository file.
+func reportPath(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// startLine returns the first line of a finding, or 1 for a finding about the
+// whole file.
+func startLine(f report.Finding) int {
+	if f.Range == nil {
+		return 1
+	}
+	return f.Range.Start.Line
+}
+
+// githubReporter writes GitHub Actions workflow commands that annotate the
+// files of a pull request. Findings at the warning level, such as ordering
+// findings, are warning annotations, and all others error annotations.
+type githubReporter struct {
+	lines []string
+}
+
+func (r *githubReporter) Add(file report.File, original, sorted []byte, explanation *sorter.Explanation) {
+	for _, f := range report.Findings(file, original, sorted, explanation) {
+		properties := []string{"file=" + githubEscapeProperty(reportPath(file.Path))}
+		if f.Range != nil {
+			properties = append(properties,
+				fmt.Sprintf("line=%d", f.Range.Start.Line),
+				fmt.Sprintf("endLine=%d", f.Range.End.Line),
+			)
+			if f.Range.Start.Line == f.Range.End.Line {
+				properties = append(properties,
+					fmt.Sprintf("col=%d", f.Range.Start.Column),
+					fmt.Sprintf("endColumn=%d", f.Range.End.Column),
+				)
+			}
+		}
+		properties = append(properties, "title="+githubEscapeProperty("tfsort "+f.Rule))
+		command := "error"
+		if f.Level == report.LevelWarning {
+			command = "warning"
+		}
+		r.lines = append(r.lines, fmt.Sprintf("::%s %s::%s\n", command, strings.Join(properties, ","), githubEscapeData(f.Message)))
+	}
+}
+
+func (r *githubReporter) Write(w io.Writer) error {
+	for _, line := range r.lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// githubEscapeData escapes the message of a workflow command.
+func githubEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubEscapeProperty escapes a property value of a workflow command.
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(githubEscapeData(s))
+}
+
+// gitlabReporter writes a GitLab Code Quality report, shown in merge requests
+// when uploaded as a codequality artifact.
+type gitlabReporter struct {
+	issues []codeQualityIssue
+}
+
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end,omitempty"`
+}
+
+func (r *gitlabReporter) Add(file report.File, original, sorted []byte, explanation *sorter.Explanation) {
+	path := reportPath(file.Path)
+	seen := make(map[string]int)
+	for _, f := range report.Findings(file, original, sorted, explanation) {
+		key := f.Rule + "\x00" + f.Subject
+		issue := codeQualityIssue{
+			Description: f.Message,
+			CheckName:   "tfsort/" + f.Rule,
+			Fingerprint: fingerprint(path, key, seen[key]),
+			Severity:    "minor",
+			Location:    codeQualityLocation{Path: path, Lines: codeQualityLines{Begin: startLine(f)}},
+		}
+		if f.Range != nil {
+			issue.Location.Lines.End = f.Range.End.Line
+		}
+		if f.Level == report.LevelError {
+			issue.Severity = "major"
+		}
+		r.issues = append(r.issues, issue)
+		seen[key]++
+	}
+}
+
+func (r *gitlabReporter) Write(w io.Writer) error {
+	issues := r.issues
+	if issues == nil {
+		issues = []codeQualityIssue{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(issues)
+}
+
+// fingerprint identifies a finding across runs by the file, the rule and
+// subject of the finding, and how many findings of the file with the same rule
+// and subject come before it. It does not depend on line numbers, so that a
+// finding keeps its fingerprint when lines above it change.
+func fingerprint(path, key string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", path, key, occurrence)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// checkstyleReporter writes a Checkstyle XML report, listing every processed
+// file with its findings.
+type checkstyleReporter struct {
+	files []checkstyleFile
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func (r *checkstyleReporter) Add(file report.File, original, sorted []byte, explanation *sorter.Explanation) {
+	if file.Status == report.StatusSkipped {
+		return
+	}
+	entry := checkstyleFile{Name: reportPath(file.Path)}
+	for _, f := range report.Findings(file, original, sorted, explanation) {
+		e := checkstyleError{
+			Line:     startLine(f),
+			Severity: f.Level,
+			Message:  f.Message,
+			Source:   "tfsort." + f.Rule,
+		}
+		if f.Range != nil {
+			e.Column = f.Range.Start.Column
+		}
+		entry.Errors = append(entry.Errors, e)
+	}
+	r.files = append(r.files, entry)
+}
+
+func (r *checkstyleReporter) Write(w io.Writer) error {
+	return writeXML(w, checkstyleReport{Version: "4.3", Files: r.files})
+}
+
+// junitReporter writes a JUnit XML report with one test case per file: a
+// changed file fails, a file that could not be sorted is an error, and a
+// skipped file is skipped.
+type junitReporter struct {
+	cases []junitTestCase
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitProblem `xml:"skipped"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+func (r *junitReporter) Add(file report.File, original, sorted []byte, explanation *sorter.Explanation) {
+	testCase := junitTestCase{Name: reportPath(file.Path), ClassName: "tfsort"}
+	var details strings.Builder
+	for _, f := range report.Findings(file, original, sorted, explanation) {
+		fmt.Fprintf(&details, "line %d: %s [%s]\n", startLine(f), f.Message, f.Rule)
+	}
+	switch file.Status {
+	case report.StatusChanged:
+		testCase.Failure = &junitProblem{Message: "The file is not sorted.", Type: "unsorted", Text: details.String()}
+	case report.StatusError:
+		testCase.Error = &junitProblem{Message: file.Error, Type: "error", Text: details.String()}
+	case report.StatusSkipped:
+		testCase.Skipped = &junitProblem{Message: file.Reason}
+	}
+	r.cases = append(r.cases, testCase)
+}
+
+func (r *junitReporter) Write(w io.Writer) error {
+	suite := junitTestSuite{Name: "tfsort", Tests: len(r.cases), Cases: r.cases}
+	for _, testCase := range r.cases {
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	return writeXML(w, junitTestSuites{
+		Name:     suite.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	})
+}
+
+// writeXML writes v as an indented XML document.
+func writeXML(w io.Writer, v any) error {
+	content, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, content)
+	return err
+}
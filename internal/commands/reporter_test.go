@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// addReporterFiles adds a changed, an unchanged, an unparsable and a skipped
+// file to r.
+func addReporterFiles(t *testing.T, r Reporter) {
+	t.Helper()
+	original := "resource \"b\" \"b\" {}\nvariable \"a\" {\n  x = [\"b\", \"a\"]\n}\n"
+	sorted := "variable \"a\" {\n  x = [\"a\", \"b\"]\n}\n\nresource \"b\" \"b\" {}\n"
+	broken := "variable \"a\" {\n"
+	_, diags := hclwrite.ParseConfig([]byte(broken), "broken.tf", hcl.InitialPos)
+
+	explanation := &sorter.Explanation{
+		Blocks: []sorter.BlockMove{{Address: "variable.a", Name: "variable.a", Before: "resource.b.b", Rank: 3}},
+		Lists:  []sorter.ListChange{{Path: "variable.a.x", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}}},
+	}
+
+	r.Add(report.File{Path: "./app/main.tf", Status: report.StatusChanged}, []byte(original), []byte(sorted), explanation)
+	r.Add(report.File{Path: "app/sorted.tf", Status: report.StatusUnchanged}, []byte(sorted), []byte(sorted), &sorter.Explanation{})
+	r.Add(report.File{Path: "app/broken.tf", Status: report.StatusError, Error: "failed to parse", Diagnostics: report.Diagnostics(diags)}, []byte(broken), nil, nil)
+	r.Add(report.File{Path: "app/empty.tf", Status: report.StatusSkipped, Reason: "empty file"}, nil, nil, nil)
+}
+
+func TestReporters(t *testing.T) {
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "github",
+			want: "::warning file=app/main.tf,line=2,endLine=4,title=tfsort block-order::variable.a is out of block type order; it belongs before resource.b.b.\n" +
+				"::warning file=app/main.tf,line=3,endLine=3,col=7,endColumn=17,title=tfsort list-order::The list in variable.a.x is not sorted.\n" +
+				"::error file=app/broken.tf,line=1,endLine=1,col=14,endColumn=15,title=tfsort parse-error::Unclosed configuration block: There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file.\n",
+		},
+		{
+			name: "checkstyle",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="app/main.tf">
+    <error line="2" column="1" severity="warning" message="variable.a is out of block type order; it belongs before resource.b.b." source="tfsort.block-order"></error>
+    <error line="3" column="7" severity="warning" message="The list in variable.a.x is not sorted." source="tfsort.list-order"></error>
+  </file>
+  <file name="app/sorted.tf"></file>
+  <file name="app/broken.tf">
+    <error line="1" column="14" severity="error" message="Unclosed configuration block: There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file." source="tfsort.parse-error"></error>
+  </file>
+</checkstyle>
+`,
+		},
+		{
+			name: "junit",
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="tfsort" tests="4" failures="1" errors="1" skipped="1">
+  <testsuite name="tfsort" tests="4" failures="1" errors="1" skipped="1">
+    <testcase name="app/main.tf" classname="tfsort">
+      <failure message="The file is not sorted." type="unsorted"><![CDATA[line 2: variable.a is out of block type order; it belongs before resource.b.b. [block-order]
+line 3: The list in variable.a.x is not sorted. [list-order]
+]]></failure>
+    </testcase>
+    <testcase name="app/sorted.tf" classname="tfsort"></testcase>
+    <testcase name="app/broken.tf" classname="tfsort">
+      <error message="failed to parse" type="error"><![CDATA[line 1: Unclosed configuration block: There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file. [parse-error]
+]]></error>
+    </testcase>
+    <testcase name="app/empty.tf" classname="tfsort">
+      <skipped message="empty file"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newReporter(formatText, tc.name)
+			if err != nil {
+				t.Fatalf("newReporter() unexpected error = %v", err)
+			}
+			addReporterFiles(t, r)
+			var b bytes.Buffer
+			if err := r.Write(&b); err != nil {
+				t.Fatalf("Write() unexpected error = %v", err)
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("Write() =\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGitLabReporter(t *testing.T) {
+	r, err := newReporter(formatText, "gitlab")
+	if err != nil {
+		t.Fatalf("newReporter() unexpected error = %v", err)
+	}
+	addReporterFiles(t, r)
+	var b bytes.Buffer
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+
+	var issues []codeQualityIssue
+	if err := json.Unmarshal(b.Bytes(), &issues); err != nil {
+		t.Fatalf("Write() wrote invalid JSON: %v\n%s", err, b.String())
+	}
+	type issue struct {
+		CheckName string
+		Severity  string
+		Path      string
+		Begin     int
+		End       int
+	}
+	var got []issue
+	fingerprints := make(map[string]bool)
+	for _, i := range issues {
+		got = append(got, issue{i.CheckName, i.Severity, i.Location.Path, i.Location.Lines.Begin, i.Location.Lines.End})
+		if len(i.Fingerprint) != 32 || fingerprints[i.Fingerprint] {
+			t.Errorf("fingerprint %q of %s is not a unique 32-digit hex string", i.Fingerprint, i.CheckName)
+		}
+		fingerprints[i.Fingerprint] = true
+	}
+	want := []issue{
+		{"tfsort/block-order", "minor", "app/main.tf", 2, 4},
+		{"tfsort/list-order", "minor", "app/main.tf", 3, 3},
+		{"tfsort/parse-error", "major", "app/broken.tf", 1, 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("issue %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNewReporterErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  string
+		wantErr string
+	}{
+		{name: "compilers", format: formatText, wantErr: "invalid reporter \"compilers\" (want one of checkstyle, github, gitlab, junit)"},
+		{name: "github", format: formatJSON, wantErr: "--reporter cannot be combined with --format json"},
+		{format: "yaml", wantErr: "invalid format \"yaml\""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name+tc.format, func(t *testing.T) {
+			_, err := newReporter(tc.format, tc.name)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("newReporter() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
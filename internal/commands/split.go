@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tjun/tfsort/internal/module"
+	"github.com/tjun/tfsort/internal/sorter"
+	"github.com/urfave/cli/v3"
+)
+
+// splitFlags defines the CLI flags for the split command.
+var splitFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name:  "layout",
+		Usage: "Move blocks to files by `LAYOUT`: conventional, provider (resources in aws.tf, ...) or service (resources in iam.tf, s3.tf, ...)",
+	},
+	&cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Print the blocks that would move and exit with non-zero status if files would change",
+	},
+}, sortFlags...)
+
+// SplitCommand returns the command that moves the blocks of a module to files
+// following a layout.
+func SplitCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "split",
+		Usage:     "Move the blocks of a module directory to files following a layout",
+		ArgsUsage: "[DIR]",
+		Flags:     splitFlags,
+		Action:    SplitAction,
+	}
+}
+
+// SplitAction moves the top-level blocks of the .tf files in a module
+// directory to the files the layout assigns them to, then sorts every file.
+func SplitAction(ctx context.Context, cmd *cli.Command) error {
+	args := cmd.Args().Slice()
+	if len(args) > 1 {
+		return cli.Exit("Error: split takes a single module directory.", 2)
+	}
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	layout, err := sorter.ParseSplitLayout(cmd.String("layout"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	sortOpts, _, err := sortOptionsFromFlags(cmd)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+
+	files, err := readModuleFiles(dir)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	if len(files) == 0 {
+		log.Printf("No .tf files found in %s.", dir)
+		return nil
+	}
+
+	result, err := sorter.SplitModule(files, layout, sortOpts)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+
+	dryRun := cmd.Bool("dry-run")
+	for _, move := range result.Moves {
+		if dryRun {
+			fmt.Printf("%s: %s -> %s\n", move.Address, move.From, move.To)
+		} else {
+			log.Printf("Moved %s from %s to %s", move.Address, move.From, move.To)
+		}
+	}
+
+	names := make([]string, 0, len(result.Files))
+	for name := range result.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hasErrors := false
+	changed := false
+	for _, name := range names {
+		content := result.Files[name]
+		original, exists := files[name]
+		if exists && content != nil && bytes.Equal(original, content) {
+			continue
+		}
+		changed = true
+		path := filepath.Join(dir, name)
+
+		switch {
+		case content == nil && dryRun:
+			log.Printf("File %s would be removed.", path)
+		case content == nil:
+			if err := os.Remove(path); err != nil {
+				log.Printf("Error removing file %s: %v", path, err)
+				hasErrors = true
+			} else {
+				log.Printf("Removed %s", path)
+			}
+		case dryRun && exists:
+			log.Printf("File %s would be changed.", path)
+		case dryRun:
+			log.Printf("File %s would be created.", path)
+		default:
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				log.Printf("Error writing file %s: %v", path, err)
+				hasErrors = true
+			} else {
+				log.Printf("Formatted %s", path)
+			}
+		}
+	}
+
+	if hasErrors {
+		return cli.Exit("Encountered errors during processing.", 2)
+	}
+	if dryRun && changed {
+		return cli.Exit("Changes would be made.", 1)
+	}
+	return nil
+}
+
+// readModuleFiles reads the .tf files of the module in dir by base name.
+// Override files are left out because Terraform merges them into the blocks
+// they override, so their blocks cannot move. The .tofu and JSON files of the
+// module are left out with a warning, as their blocks are not reorganized.
+func readModuleFiles(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module directory: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if filepath.Ext(name) != ".tf" {
+			if isModuleConfigFile(name) {
+				log.Printf("Warning: skipping %q; only .tf files are reorganized", name)
+			}
+			continue
+		}
+		if module.IsOverrideFile(name) {
+			log.Printf("Warning: skipping override file %q", name)
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+func TestSplitAction(t *testing.T) {
+	module := map[string]string{
+		"main.tf":       "variable \"name\" {}\n\nresource \"aws_iam_role\" \"app\" {\n  name = var.name\n}\n",
+		"override.tf":   "variable \"name\" {\n  default = \"x\"\n}\n",
+		"variables.tf":  "variable \"env\" {}\n",
+		"notes/todo.tf": "output \"x\" {\n  value = 1\n}\n",
+		"extra.tf.json": "{\"output\": {\"y\": {\"value\": 1}}}\n",
+		"extra.tofu":    "output \"z\" {\n  value = 1\n}\n",
+	}
+
+	testCases := []struct {
+		name                string
+		args                []string
+		wantStdout          string
+		wantFiles           map[string]string // An empty string means the file must not exist
+		wantExitCode        int
+		wantErrMsgSubstring string
+	}{
+		{
+			name:       "dry-run reports moves",
+			args:       []string{"--dry-run"},
+			wantStdout: "variable.name: main.tf -> variables.tf\n",
+			wantFiles: map[string]string{
+				"main.tf":      module["main.tf"],
+				"variables.tf": module["variables.tf"],
+			},
+			wantExitCode: 1,
+		},
+		{
+			name: "conventional layout",
+			wantFiles: map[string]string{
+				"main.tf":       "resource \"aws_iam_role\" \"app\" {\n  name = var.name\n}\n",
+				"override.tf":   module["override.tf"],
+				"variables.tf":  "variable \"env\" {}\n\nvariable \"name\" {}\n",
+				"notes/todo.tf": module["notes/todo.tf"],
+				"extra.tf.json": module["extra.tf.json"],
+				"extra.tofu":    module["extra.tofu"],
+				"outputs.tf":    "",
+			},
+		},
+		{
+			name: "service layout",
+			args: []string{"--layout=service"},
+			wantFiles: map[string]string{
+				"iam.tf":       "resource \"aws_iam_role\" \"app\" {\n  name = var.name\n}\n",
+				"main.tf":      "",
+				"variables.tf": "variable \"env\" {}\n\nvariable \"name\" {}\n",
+			},
+		},
+		{
+			name:                "invalid layout",
+			args:                []string{"--layout=alphabetical"},
+			wantFiles:           map[string]string{"main.tf": module["main.tf"]},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid split layout",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, cleanup := setupTestFiles(t, module)
+			defer cleanup()
+
+			app := &cli.Command{
+				Name:     "tfsort-test-app",
+				Commands: []*cli.Command{SplitCommand()},
+				ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+					// Prevent os.Exit during tests
+				},
+			}
+
+			var actionErr error
+			stdout := captureOutput(t, func() {
+				runArgs := append([]string{app.Name, "split"}, tc.args...)
+				actionErr = app.Run(context.Background(), append(runArgs, tmpDir))
+			})
+
+			exitCode := 0
+			if actionErr != nil {
+				exitCode = 2
+				if exitCoder, ok := actionErr.(cli.ExitCoder); ok {
+					exitCode = exitCoder.ExitCode()
+				}
+			}
+			if exitCode != tc.wantExitCode {
+				t.Errorf("exit code = %d, want %d (error: %v)", exitCode, tc.wantExitCode, actionErr)
+			}
+			if tc.wantErrMsgSubstring != "" && (actionErr == nil || !strings.Contains(actionErr.Error(), tc.wantErrMsgSubstring)) {
+				t.Errorf("error = %v, want error containing %q", actionErr, tc.wantErrMsgSubstring)
+			}
+			if stdout != tc.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tc.wantStdout)
+			}
+
+			for name, want := range tc.wantFiles {
+				content, err := os.ReadFile(filepath.Join(tmpDir, name))
+				if want == "" {
+					if !os.IsNotExist(err) {
+						t.Errorf("file %s exists, want it removed", name)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("failed to read %s: %v", name, err)
+					continue
+				}
+				if string(content) != want {
+					t.Errorf("file %s content mismatch\nGot:\n%s\nWant:\n%s", name, content, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// runSummary collects the summary statistics of --summary.
+type runSummary struct {
+	report.Summary
+	byDirectory bool
+	start       time.Time
+}
+
+// summaryReporter is a reporter that includes the summary in its report
+// instead of having it printed as a table.
+type summaryReporter interface {
+	SetSummary(summary *report.Summary)
+}
+
+// newRunSummary returns a summary of a run that starts now.
+func newRunSummary(byDirectory bool) *runSummary {
+	return &runSummary{byDirectory: byDirectory, start: time.Now()}
+}
+
+// add counts a file with its final status. changes are the changes located
+// in the file, as the JSON report lists them, and explanation is the record of
+// the changes sorting made to the file, or nil if it was not sorted; neither is
+// counted for a file that could not be written.
+func (s *runSummary) add(path string, status report.Status, changes []report.Change, explanation *sorter.Explanation) {
+	counts := report.Counts{Files: 1}
+	switch status {
+	case report.StatusChanged:
+		counts.Changed = 1
+	case report.StatusUnchanged:
+		counts.Unchanged = 1
+	case report.StatusSkipped:
+		counts.Skipped = 1
+	case report.StatusError:
+		counts.Errors = 1
+	}
+	if status != report.StatusError {
+		for _, change := range changes {
+			switch change.Kind {
+			case report.ChangeBlockMoved:
+				counts.BlocksReordered++
+			case report.ChangeListSorted:
+				counts.ListsSorted++
+			}
+		}
+		if explanation != nil {
+			for _, list := range explanation.Lists {
+				if list.Skipped == sorter.SkipIgnoreDirective {
+					counts.ListsIgnored++
+				}
+			}
+		}
+	}
+
+	dir := ""
+	if s.byDirectory {
+		dir = "<stdin>"
+		if path != "<stdin>" {
+			dir = filepath.ToSlash(filepath.Dir(path))
+		}
+	}
+	s.Add(dir, counts)
+}
+
+// finish records the elapsed time and returns the summary.
+func (s *runSummary) finish() *report.Summary {
+	s.ElapsedSeconds = math.Round(time.Since(s.start).Seconds()*1000) / 1000
+	return &s.Summary
+}
+
+// writeSummaryTable writes the summary as a table with a row per directory, if
+// broken down by directory, and a row of totals.
+func writeSummaryTable(w io.Writer, summary *report.Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DIRECTORY\tFILES\tCHANGED\tUNCHANGED\tSKIPPED\tERRORS\tBLOCKS REORDERED\tLISTS SORTED\tLISTS IGNORED")
+	row := func(name string, c report.Counts) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			name, c.Files, c.Changed, c.Unchanged, c.Skipped, c.Errors, c.BlocksReordered, c.ListsSorted, c.ListsIgnored)
+	}
+	for _, dir := range summary.Directories {
+		row(dir.Path, dir.Counts)
+	}
+	row("total", summary.Counts)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Elapsed: %.3fs\n", summary.ElapsedSeconds)
+	return err
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/urfave/cli/v3"
+)
+
+func TestWriteSummaryTable(t *testing.T) {
+	summary := &report.Summary{
+		Counts:         report.Counts{Files: 3, Changed: 1, Unchanged: 1, Skipped: 1, BlocksReordered: 2, ListsSorted: 1},
+		ElapsedSeconds: 1.5,
+		Directories: []report.DirectorySummary{
+			{Path: ".", Counts: report.Counts{Files: 1, Skipped: 1}},
+			{Path: "modules/vpc", Counts: report.Counts{Files: 2, Changed: 1, Unchanged: 1, BlocksReordered: 2, ListsSorted: 1}},
+		},
+	}
+	var b strings.Builder
+	if err := writeSummaryTable(&b, summary); err != nil {
+		t.Fatalf("writeSummaryTable() unexpected error = %v", err)
+	}
+	want := `DIRECTORY    FILES  CHANGED  UNCHANGED  SKIPPED  ERRORS  BLOCKS REORDERED  LISTS SORTED  LISTS IGNORED
+.            1      0        0          1        0       0                 0             0
+modules/vpc  2      1        1          0        0       2                 1             0
+total        3      1        1          1        0       2                 1             0
+Elapsed: 1.500s
+`
+	if got := b.String(); got != want {
+		t.Errorf("writeSummaryTable() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTfsortActionSummary(t *testing.T) {
+	tmpDir, cleanup := setupTestFiles(t, map[string]string{
+		"broken.tf":       "variable \"a\" {\n",
+		"empty.tf":        "",
+		"vpc/sorted.tf":   "variable \"a\" {\n  x = [ # tfsort:ignore\n    2,\n    1,\n  ]\n}\n",
+		"vpc/unsorted.tf": "resource \"b\" \"b\" {}\nresource \"a\" \"a\" {\n  x = [2, 1]\n}\nvariable \"a\" {}\n",
+	})
+	defer cleanup()
+	t.Chdir(tmpDir)
+	originalIsInputFromPipe := isInputFromPipe
+	defer func() { isInputFromPipe = originalIsInputFromPipe }()
+	isInputFromPipe = func() bool { return false }
+
+	app := &cli.Command{
+		Name:   "tfsort-test-app",
+		Flags:  GetFlags(),
+		Action: TfsortAction,
+		ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+			// Prevent os.Exit during tests
+		},
+	}
+
+	var actionErr error
+	stdout := captureOutput(t, func() {
+		actionErr = app.Run(context.Background(), []string{app.Name, "--format", "json", "--summary-by-dir", "--dry-run", "-r", "."})
+	})
+	if exitCoder, ok := actionErr.(cli.ExitCoder); !ok || exitCoder.ExitCode() != 2 {
+		t.Errorf("error = %v, want exit code 2", actionErr)
+	}
+
+	var got report.Report
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout)
+	}
+	if got.Summary == nil {
+		t.Fatalf("report has no summary:\n%s", stdout)
+	}
+	want := report.Counts{Files: 4, Changed: 1, Unchanged: 1, Skipped: 1, Errors: 1, BlocksReordered: 2, ListsSorted: 1, ListsIgnored: 1}
+	if got.Summary.Counts != want {
+		t.Errorf("summary = %+v, want %+v", got.Summary.Counts, want)
+	}
+	wantDirs := []report.DirectorySummary{
+		{Path: ".", Counts: report.Counts{Files: 2, Skipped: 1, Errors: 1}},
+		{Path: "vpc", Counts: report.Counts{Files: 2, Changed: 1, Unchanged: 1, BlocksReordered: 2, ListsSorted: 1, ListsIgnored: 1}},
+	}
+	if len(got.Summary.Directories) != len(wantDirs) {
+		t.Fatalf("directories = %+v, want %+v", got.Summary.Directories, wantDirs)
+	}
+	for i := range wantDirs {
+		if got.Summary.Directories[i] != wantDirs[i] {
+			t.Errorf("directories[%d] = %+v, want %+v", i, got.Summary.Directories[i], wantDirs[i])
+		}
+	}
+}
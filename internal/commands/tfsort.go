@@ -3,6 +3,7 @@ package commands
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,7 +11,13 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/tjun/tfsort/internal/config"
+	"github.com/tjun/tfsort/internal/diff"
+	"github.com/tjun/tfsort/internal/module"
 	"github.com/tjun/tfsort/internal/parser"
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/schema"
 	"github.com/tjun/tfsort/internal/sorter"
 	"github.com/urfave/cli/v3"
 )
@@ -21,18 +28,87 @@ type InputSource struct {
 	Content []byte
 }
 
+// Output formats of the tfsort command.
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
 // flags defines the CLI flags for the tfsort command.
-var flags = []cli.Flag{
+var flags = append(append([]cli.Flag{
 	&cli.BoolFlag{
 		Name:    "recursive",
 		Aliases: []string{"r"},
-		Usage:   "Walk directories recursively and process all `*.tf` files",
+		Usage:   "Walk directories recursively and process all supported files, such as `*.tf`, `*.tfvars`, `*.tofu`, `*.tftest.hcl`, `terragrunt.hcl` and `*.pkr.hcl`",
+	},
+	&cli.BoolFlag{
+		Name:  "follow-modules",
+		Usage: "Process the directories given as root modules and the local modules they call, transitively, instead of walking directories",
 	},
 	&cli.BoolFlag{
 		Name:    "in-place",
 		Aliases: []string{"i"},
 		Usage:   "Overwrite files in place instead of printing to stdout",
 	},
+}, sortFlags...),
+	&cli.BoolFlag{
+		Name:  "check-duplicates",
+		Usage: "Report blocks, locals and required_providers entries defined more than once across the files of a module directory",
+	},
+	&cli.BoolFlag{
+		Name:  "explain",
+		Usage: "Print why each file changes to stderr: the blocks moved with their sort keys, and the lists sorted or skipped with the reason",
+	},
+	&cli.BoolFlag{
+		Name:  "summary",
+		Usage: "Print summary statistics of the run to stderr at the end, or include them in the report of --format json",
+	},
+	&cli.BoolFlag{
+		Name:  "summary-by-dir",
+		Usage: "Break the summary statistics down by directory (implies --summary)",
+	},
+	&cli.StringFlag{
+		Name:  "format",
+		Value: formatText,
+		Usage: "Output `FORMAT`: text (the sorted content), json (a report of the status and changes of every file) or sarif (SARIF 2.1.0 results for code scanning)",
+	},
+	&cli.StringFlag{
+		Name:  "reporter",
+		Usage: "Report the outcome for a CI system with `NAME`: checkstyle, github, gitlab or junit",
+	},
+	&cli.BoolFlag{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "List the files whose sorted content differs instead of printing it; with --in-place, the files rewritten",
+	},
+	&cli.BoolFlag{
+		Name:  "null",
+		Usage: "Separate the paths listed by --list with NUL characters instead of newlines (also -0)",
+	},
+	&cli.BoolFlag{
+		Name:  "diff",
+		Usage: "Print a unified diff of the changes instead of the sorted content",
+	},
+	&cli.IntFlag{
+		Name:  "diff-context",
+		Value: diff.DefaultContext,
+		Usage: "Show `N` unchanged lines around each change with --diff",
+	},
+	&cli.StringFlag{
+		Name:  "color",
+		Value: "auto",
+		Usage: "Color the --diff output `WHEN`: auto (if stdout is a terminal), always or never",
+	},
+	&cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Exit with non-zero status if changes would be made",
+	},
+)
+
+// sortFlags defines the flags that control how files are sorted. They are
+// shared by the tfsort command and its subcommands.
+var sortFlags = []cli.Flag{
 	&cli.BoolFlag{
 		Name:  "no-sort-blocks",
 		Value: false,
@@ -49,8 +125,48 @@ var flags = []cli.Flag{
 		Usage: "Disable sorting of list attribute values",
 	},
 	&cli.BoolFlag{
-		Name:  "dry-run",
-		Usage: "Exit with non-zero status if changes would be made",
+		Name:  "no-sort-tfvars",
+		Value: false,
+		Usage: "Keep top-level assignments (e.g. in .tfvars files) in their original order",
+	},
+	&cli.BoolFlag{
+		Name:  "no-sort-object-type",
+		Value: false,
+		Usage: "Disable sorting of attributes in object type constraints of variable blocks",
+	},
+	&cli.StringFlag{
+		Name:  "sort-locals",
+		Usage: "Order keys in locals blocks by `MODE`: alphabetical or dependency (default: keep original order)",
+	},
+	&cli.BoolFlag{
+		Name:  "merge-locals",
+		Value: false,
+		Usage: "Merge all locals blocks of a file into the first one",
+	},
+	&cli.StringFlag{
+		Name:  "variable-order",
+		Usage: "Order variable blocks by `MODE`: required-first puts variables without a default first, each group sorted by name",
+	},
+	&cli.BoolFlag{
+		Name:  "variable-group-headers",
+		Value: false,
+		Usage: "Add a section header comment above required and optional variables (with --variable-order=required-first)",
+	},
+	&cli.StringFlag{
+		Name:  "section-headers",
+		Usage: "Insert section header comments between groups of sorted blocks, by `STYLE`: block-type or prefix",
+	},
+	&cli.StringFlag{
+		Name:  "dialect",
+		Usage: "Sort all files with the rules of dialect `NAME`, such as terraform, opentofu, terragrunt, packer or a profile from the config file (default: chosen by file name)",
+	},
+	&cli.StringFlag{
+		Name:  "config",
+		Usage: "Load custom dialect profiles from the HCL config `FILE` (default: .tfsort.hcl if it exists)",
+	},
+	&cli.StringFlag{
+		Name:  "provider-schema",
+		Usage: "Order resource/data arguments using the `FILE` written by 'terraform providers schema -json'",
 	},
 }
 
@@ -73,21 +189,75 @@ func TfsortAction(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
+	sortOpts, profiles, err := sortOptionsFromFlags(cmd)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+
+	showDiff := cmd.Bool("diff")
+	diffOptions := diff.Options{Context: int(cmd.Int("diff-context"))}
+	if diffOptions.Context < 0 {
+		return cli.Exit(fmt.Sprintf("Error: invalid diff context %d (want 0 or more lines)", diffOptions.Context), 2)
+	}
+	switch when := cmd.String("color"); when {
+	case "always":
+		diffOptions.Color = true
+	case "auto":
+		diffOptions.Color = isOutputToTerminal()
+	case "never":
+	default:
+		return cli.Exit(fmt.Sprintf("Error: invalid color mode %q (want \"auto\", \"always\" or \"never\")", when), 2)
+	}
+
+	list := cmd.Bool("list")
+	listSeparator := "\n"
+	if cmd.Bool("null") {
+		if !list {
+			return cli.Exit("Error: -0/--null can only be used with --list.", 2)
+		}
+		listSeparator = "\x00"
+	}
+
+	format := cmd.String("format")
+	reporter, err := newReporter(format, cmd.String("reporter"))
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Error: %v", err), 2)
+	}
+	if reporter != nil && (showDiff || list) {
+		if name := cmd.String("reporter"); name != "" {
+			return cli.Exit(fmt.Sprintf("Error: --reporter %s cannot be combined with --diff or --list.", name), 2)
+		}
+		return cli.Exit(fmt.Sprintf("Error: --format %s cannot be combined with --diff or --list.", format), 2)
+	}
+
 	recursive := cmd.Bool("recursive")
+	followModules := cmd.Bool("follow-modules")
+	if recursive && followModules {
+		return cli.Exit("Error: --recursive and --follow-modules cannot be used together.", 2)
+	}
 
-	// Call the processing function with extracted values
-	sources, err := processInputs(args, recursive) // Renamed function
+	var summary *runSummary
+	if cmd.Bool("summary") || cmd.Bool("summary-by-dir") {
+		summary = newRunSummary(cmd.Bool("summary-by-dir"))
+	}
+
+	if len(args) == 0 && isInputFromPipe() && reporter == nil {
+		fmt.Fprintln(os.Stderr, "Reading from stdin...")
+	}
+	sources, skipped, err := collectInputs(args, recursive, followModules, profiles)
 	if err != nil {
-		return fmt.Errorf("failed to process inputs: %w", err)
+		return cli.Exit(fmt.Sprintf("Error: failed to process inputs: %v", err), 2)
 	}
 
 	if len(sources) == 0 {
-		if len(args) > 0 { // Check if args were originally provided
-			log.Println("No input files found.")
-		} else if !isInputFromPipe() {
-			log.Println("No input files specified and no data piped from stdin.")
+		if reporter == nil {
+			if len(args) > 0 { // Check if args were originally provided
+				log.Println("No input files found.")
+			} else if !isInputFromPipe() {
+				log.Println("No input files specified and no data piped from stdin.")
+			}
 		}
-		return nil
+		return finishRun(reporter, summary, skipped, 0)
 	}
 
 	hasErrors := false
@@ -96,43 +266,80 @@ func TfsortAction(ctx context.Context, cmd *cli.Command) error {
 	inPlace := cmd.Bool("in-place")
 	dryRun := cmd.Bool("dry-run")
 
-	sortOpts := sorter.SortOptions{
-		SortBlocks:   !cmd.Bool("no-sort-blocks"),
-		SortTypeName: !cmd.Bool("no-sort-type-name"),
-		SortList:     !cmd.Bool("no-sort-list"),
+	if cmd.Bool("check-duplicates") && reportDuplicates(sources) {
+		hasErrors = true
 	}
 
-	for _, source := range sources {
-		log.Printf("Processing: %s", source.Path)
-		hclFile, parseDiags := parser.ParseHCL(source.Content, source.Path)
+	printContent := !showDiff && !list && reporter == nil
+	explain := cmd.Bool("explain")
 
-		if parseDiags.HasErrors() {
-			log.Printf("Error parsing %s: %v", source.Path, parseDiags)
-			hasErrors = true
-			continue
-		}
-		if hclFile == nil { // Should not happen if no errors, but good to check
-			log.Printf("Error parsing %s: parsed file is nil despite no errors", source.Path)
-			hasErrors = true
-			continue
+	reported := 0 // The skipped inputs added to the report
+	for i, source := range sources {
+		if reporter != nil {
+			for ; reported < len(skipped) && skipped[reported].Position <= i; reported++ {
+				addSkipped(reporter, skipped[reported])
+			}
 		}
-
+		log.Printf("Processing: %s", source.Path)
 		originalBytes := make([]byte, len(source.Content))
 		copy(originalBytes, source.Content)
 
-		// Call the modified Sort function which returns a new file object
-		sortedFile, err := sorter.Sort(hclFile, sortOpts)
+		entry := report.File{Path: source.Path, Status: report.StatusUnchanged}
+		// The explanation is the record of the changes that the explanation,
+		// reports and summary all describe. It is only kept when one of them
+		// is requested.
+		opts := sortOpts
+		if explain || reporter != nil || summary != nil {
+			opts.Explain = &sorter.Explanation{}
+		}
+		sortedBytes, jsonSyntax, err := sortSource(source, opts, profiles)
 		if err != nil {
-			log.Printf("Error sorting %s: %v", source.Path, err)
+			log.Printf("Error processing %s: %v", source.Path, err)
 			hasErrors = true
+			entry.Status = report.StatusError
+			entry.Error = err.Error()
+			var diagsErr *diagnosticsError
+			if errors.As(err, &diagsErr) {
+				entry.Diagnostics = report.Diagnostics(diagsErr.Diagnostics)
+			}
+			if reporter != nil {
+				reporter.Add(entry, originalBytes, nil, nil)
+			}
+			if summary != nil {
+				summary.add(source.Path, entry.Status, nil, nil)
+			}
 			continue
 		}
-
-		// If the returned file pointer is the same as the original, no changes were made.
-		// Otherwise, compare bytes for robustness (though pointer check should suffice if Sort guarantees it).
-		sortedBytes := sortedFile.Bytes()
 		changed := !bytes.Equal(originalBytes, sortedBytes)
-		// Alternative change detection: changed := sortedFile != hclFile (if Sort guarantees returning original on no change)
+		if changed {
+			entry.Status = report.StatusChanged
+		}
+		if changed && (reporter != nil || summary != nil) {
+			// The JSON report and the summary both count these changes.
+			entry.Changes = report.Changes(originalBytes, sortedBytes, source.Path, opts.Explain)
+		}
+
+		if explain {
+			explanation := opts.Explain
+			if jsonSyntax {
+				explanation = nil
+			}
+			if err := writeExplanation(os.Stderr, source.Path, changed, explanation); err != nil {
+				log.Printf("Error writing explanation for %s: %v", source.Path, err)
+				hasErrors = true
+			}
+		}
+
+		if showDiff && changed {
+			if _, err := os.Stdout.Write(diff.Unified(source.Path, originalBytes, sortedBytes, diffOptions)); err != nil {
+				log.Printf("Error writing diff for %s: %v", source.Path, err)
+				hasErrors = true
+			}
+		}
+
+		if list && changed && !(inPlace && !dryRun) {
+			listPath(source.Path, listSeparator)
+		}
 
 		if dryRun {
 			if changed {
@@ -140,7 +347,9 @@ func TfsortAction(ctx context.Context, cmd *cli.Command) error {
 				log.Printf("File %s would be changed.", source.Path)
 			}
 		} else if inPlace {
-			if source.Path == "<stdin>" {
+			if source.Path == "<stdin>" && !printContent {
+				log.Println("Warning: cannot write in-place for stdin input.")
+			} else if source.Path == "<stdin>" {
 				log.Println("Warning: cannot write in-place for stdin input. Writing to stdout instead.")
 				_, err := os.Stdout.Write(sortedBytes)
 				if err != nil {
@@ -151,19 +360,34 @@ func TfsortAction(ctx context.Context, cmd *cli.Command) error {
 				if err := os.WriteFile(source.Path, sortedBytes, 0644); err != nil {
 					log.Printf("Error writing file %s: %v", source.Path, err)
 					hasErrors = true
+					entry.Status = report.StatusError
+					entry.Error = err.Error()
 				} else {
 					log.Printf("Formatted %s", source.Path)
+					if list {
+						listPath(source.Path, listSeparator)
+					}
 				}
 			} else {
 				log.Printf("No changes for %s", source.Path)
 			}
-		} else { // Default: print to stdout
+		} else if printContent { // Default: print to stdout
 			_, err := os.Stdout.Write(sortedBytes)
 			if err != nil {
 				log.Printf("Error writing to stdout for %s: %v", source.Path, err)
 				hasErrors = true
 			}
 		}
+		if reporter != nil {
+			reporter.Add(entry, originalBytes, sortedBytes, opts.Explain)
+		}
+		if summary != nil {
+			summary.add(source.Path, entry.Status, entry.Changes, opts.Explain)
+		}
+	}
+
+	if err := finishRun(reporter, summary, skipped, reported); err != nil {
+		return err
 	}
 
 	if hasErrors {
@@ -177,36 +401,184 @@ func TfsortAction(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
-// processInputs determines the target HCL sources based on arguments and flags.
-func processInputs(args []string, recursive bool) ([]InputSource, error) {
+// sortOptionsFromFlags builds the sort options from the sort flags of cmd and
+// returns them together with the dialect profiles available to the run.
+func sortOptionsFromFlags(cmd *cli.Command) (sorter.SortOptions, *sorter.ProfileSet, error) {
+	localsOrder, err := sorter.ParseLocalsOrder(cmd.String("sort-locals"))
+	if err != nil {
+		return sorter.SortOptions{}, nil, err
+	}
+
+	variableOrder, err := sorter.ParseVariableOrder(cmd.String("variable-order"))
+	if err != nil {
+		return sorter.SortOptions{}, nil, err
+	}
+
+	sectionHeaders, err := sorter.ParseSectionHeaderStyle(cmd.String("section-headers"))
+	if err != nil {
+		return sorter.SortOptions{}, nil, err
+	}
+
+	profiles, err := loadProfiles(cmd.String("config"))
+	if err != nil {
+		return sorter.SortOptions{}, nil, err
+	}
+
+	var profile *sorter.Profile
+	if name := cmd.String("dialect"); name != "" {
+		profile, err = profiles.Lookup(name)
+		if err != nil {
+			return sorter.SortOptions{}, nil, err
+		}
+	}
+
+	var providerSchema *schema.ProviderSchemas
+	if path := cmd.String("provider-schema"); path != "" {
+		providerSchema, err = schema.Load(path)
+		if err != nil {
+			return sorter.SortOptions{}, nil, err
+		}
+	}
+
+	return sorter.SortOptions{
+		SortBlocks:           !cmd.Bool("no-sort-blocks"),
+		SortTypeName:         !cmd.Bool("no-sort-type-name"),
+		SortList:             !cmd.Bool("no-sort-list"),
+		SortAttributes:       !cmd.Bool("no-sort-tfvars"),
+		SortObjectTypes:      !cmd.Bool("no-sort-object-type"),
+		SortLocals:           localsOrder,
+		MergeLocals:          cmd.Bool("merge-locals"),
+		VariableOrder:        variableOrder,
+		VariableGroupHeaders: cmd.Bool("variable-group-headers"),
+		SectionHeaders:       sectionHeaders,
+		Profile:              profile,
+		ProviderSchema:       providerSchema,
+	}, profiles, nil
+}
+
+// sortSource sorts the content of source and returns the sorted bytes. Files in
+// JSON syntax are sorted with the JSON backend, everything else as native HCL;
+// jsonSyntax reports whether the JSON backend ran, which records no
+// explanation. Unless a dialect is set in opts, it is chosen from profiles by
+// the file name.
+func sortSource(source InputSource, opts sorter.SortOptions, profiles *sorter.ProfileSet) (sorted []byte, jsonSyntax bool, err error) {
+	if opts.Profile == nil {
+		opts.Profile = profiles.ForFile(source.Path)
+	}
+
+	switch {
+	case strings.HasSuffix(source.Path, ".tfvars.json"), strings.HasSuffix(source.Path, ".pkrvars.json"):
+		jsonFile, err := parser.ParseJSON(source.Content, source.Path)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to parse: %w", err)
+		}
+		sorter.SortJSONVariables(jsonFile, opts)
+		return jsonFile.Bytes(), true, nil
+	case strings.HasSuffix(source.Path, ".json"):
+		jsonFile, err := parser.ParseJSON(source.Content, source.Path)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to parse: %w", err)
+		}
+		if err := sorter.SortJSON(jsonFile, opts); err != nil {
+			return nil, true, fmt.Errorf("failed to sort: %w", err)
+		}
+		return jsonFile.Bytes(), true, nil
+	}
+
+	hclFile, parseDiags := parser.ParseHCL(source.Content, source.Path)
+	if parseDiags.HasErrors() {
+		return nil, false, fmt.Errorf("failed to parse: %w", &diagnosticsError{Diagnostics: parseDiags})
+	}
+	if hclFile == nil { // Should not happen if no errors, but good to check
+		return nil, false, fmt.Errorf("failed to parse: parsed file is nil despite no errors")
+	}
+
+	sortedFile, err := sorter.Sort(hclFile, opts)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to sort: %w", err)
+	}
+	return sortedFile.Bytes(), false, nil
+}
+
+// diagnosticsError is a parse error that keeps the diagnostics of the parser.
+type diagnosticsError struct {
+	Diagnostics hcl.Diagnostics
+}
+
+func (e *diagnosticsError) Error() string {
+	return e.Diagnostics.Error()
+}
+
+// skippedInput is an argument, or a file found for one, that is not processed.
+type skippedInput struct {
+	Path   string
+	Reason string
+	// Position is the number of sources collected before the input, which
+	// places it among the sources in the order of the inputs.
+	Position int
+}
+
+// collectInputs determines the target HCL sources based on arguments and
+// flags, and also returns the inputs it skips, with the reason. With
+// followModules, directories are root modules whose local module calls are
+// followed; the module tree is printed to stderr.
+func collectInputs(args []string, recursive, followModules bool, profiles *sorter.ProfileSet) ([]InputSource, []skippedInput, error) {
 	var sources []InputSource
+	var skipped []skippedInput
+	skip := func(path, reason string) {
+		skipped = append(skipped, skippedInput{Path: path, Reason: reason, Position: len(sources)})
+	}
 
 	if len(args) == 0 && isInputFromPipe() {
-		fmt.Println("Reading from stdin...")
 		content, err := io.ReadAll(os.Stdin)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read from stdin: %w", err)
+			return nil, nil, fmt.Errorf("failed to read from stdin: %w", err)
 		}
 		if len(content) > 0 {
 			sources = append(sources, InputSource{Path: "<stdin>", Content: content})
 		}
 	} else if len(args) > 0 {
+		// Arguments skipped before the files are read are kept with the
+		// number of file paths found before them, to be placed among the
+		// sources once they are read.
 		var filePaths []string
+		var skippedArgs []skippedInput
+		skipArg := func(path, reason string) {
+			skippedArgs = append(skippedArgs, skippedInput{Path: path, Reason: reason, Position: len(filePaths)})
+		}
 		for _, arg := range args {
 			info, err := os.Stat(arg)
 			if err != nil {
 				log.Printf("Warning: could not stat %q: %v", arg, err)
+				skipArg(arg, err.Error())
 				continue
 			}
 
 			if info.IsDir() {
-				if recursive {
+				if followModules {
+					tree, err := module.LoadTree(arg)
+					if err != nil {
+						return nil, nil, err
+					}
+					fmt.Fprint(os.Stderr, tree)
+					for _, err := range tree.Errors() {
+						log.Printf("Warning: %v", err)
+					}
+					for _, dir := range tree.Dirs() {
+						paths, err := moduleFiles(dir, profiles)
+						if err != nil {
+							log.Printf("Warning: %v", err)
+							continue
+						}
+						filePaths = append(filePaths, paths...)
+					}
+				} else if recursive {
 					err := filepath.WalkDir(arg, func(path string, d os.DirEntry, err error) error {
 						if err != nil {
 							log.Printf("Warning: error accessing path %q: %v", path, err)
 							return nil
 						}
-						if !d.IsDir() && strings.HasSuffix(d.Name(), ".tf") {
+						if !d.IsDir() && profiles.ForFile(d.Name()) != nil {
 							filePaths = append(filePaths, path)
 						}
 						return nil
@@ -216,17 +588,20 @@ func processInputs(args []string, recursive bool) ([]InputSource, error) {
 					}
 				} else {
 					log.Printf("Warning: skipping directory %q (use -r to process recursively)", arg)
+					skipArg(arg, "directory (use -r to process recursively)")
 				}
-			} else if strings.HasSuffix(info.Name(), ".tf") {
+			} else if profiles.ForFile(info.Name()) != nil {
 				filePaths = append(filePaths, arg)
 			} else {
-				log.Printf("Warning: skipping non-.tf file %q", arg)
+				log.Printf("Warning: skipping unsupported file %q", arg)
+				skipArg(arg, "unsupported file")
 			}
 		}
 
 		seen := make(map[string]bool)
 		uniquePaths := []string{}
-		for _, p := range filePaths {
+		unique := make([]bool, len(filePaths))
+		for i, p := range filePaths {
 			absPath, err := filepath.Abs(p)
 			if err != nil {
 				log.Printf("Warning: could not get absolute path for %q: %v", p, err)
@@ -234,25 +609,240 @@ func processInputs(args []string, recursive bool) ([]InputSource, error) {
 			}
 			if !seen[absPath] {
 				seen[absPath] = true
+				unique[i] = true
 				uniquePaths = append(uniquePaths, p)
 			}
 		}
 
-		for _, path := range uniquePaths {
+		warnShadowedFiles(uniquePaths)
+
+		for i, path := range filePaths {
+			for len(skippedArgs) > 0 && skippedArgs[0].Position <= i {
+				skip(skippedArgs[0].Path, skippedArgs[0].Reason)
+				skippedArgs = skippedArgs[1:]
+			}
+			if !unique[i] {
+				continue
+			}
 			content, err := os.ReadFile(path)
 			if err != nil {
 				log.Printf("Warning: failed to read file %q: %v", path, err)
+				skip(path, err.Error())
 				continue
 			}
 			if len(content) > 0 {
 				sources = append(sources, InputSource{Path: path, Content: content})
 			} else {
 				log.Printf("Warning: skipping empty file %q", path)
+				skip(path, "empty file")
 			}
 		}
+		for _, input := range skippedArgs {
+			skip(input.Path, input.Reason)
+		}
+	}
+
+	return sources, skipped, nil
+}
+
+// moduleFiles returns the supported files directly inside a module directory.
+func moduleFiles(dir string, profiles *sorter.ProfileSet) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read module directory %q: %w", dir, err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && profiles.ForFile(entry.Name()) != nil {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// loadProfiles returns the dialect profiles extended with those of the config
+// file at path.
+func loadProfiles(path string) (*sorter.ProfileSet, error) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.ProfileSet()
+}
+
+// loadConfig loads the config file at path. Without a path, the default config
+// file of the working directory is loaded if it exists; otherwise loadConfig
+// returns nil.
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		if _, err := os.Stat(config.DefaultFile); err != nil {
+			return nil, nil
+		}
+		path = config.DefaultFile
 	}
+	return config.Load(path)
+}
+
+// warnShadowedFiles logs a warning for every OpenTofu file that has a Terraform
+// file of the same name next to it. OpenTofu loads only the .tofu file.
+func warnShadowedFiles(paths []string) {
+	shadowed := map[string]string{".tofu": ".tf", ".tofu.json": ".tf.json"}
+	for _, path := range paths {
+		for tofuExt, tfExt := range shadowed {
+			if !strings.HasSuffix(path, tofuExt) {
+				continue
+			}
+			tfPath := strings.TrimSuffix(path, tofuExt) + tfExt
+			if _, err := os.Stat(tfPath); err == nil {
+				log.Printf("Warning: both %q and %q exist; OpenTofu only loads %q", tfPath, path, path)
+			}
+		}
+	}
+}
+
+// reportDuplicates groups the Terraform and OpenTofu files among sources, in
+// native and JSON syntax, by module directory and logs every address defined
+// more than once within a module. It returns true if any duplicate was found.
+// A .tf or .tf.json file is left out when a .tofu or .tofu.json file of the
+// same name replaces it.
+func reportDuplicates(sources []InputSource) bool {
+	modules := make(map[string]map[string][]byte)
+	var dirs []string
+	for _, source := range sources {
+		if source.Path == "<stdin>" {
+			continue
+		}
+		if !isModuleConfigFile(source.Path) {
+			continue
+		}
+		dir := filepath.Dir(source.Path)
+		if _, ok := modules[dir]; !ok {
+			modules[dir] = make(map[string][]byte)
+			dirs = append(dirs, dir)
+		}
+		modules[dir][source.Path] = source.Content
+	}
+
+	found := false
+	for _, dir := range dirs {
+		files := modules[dir]
+		for path := range files {
+			for tfExt, tofuExt := range map[string]string{".tf": ".tofu", ".tf.json": ".tofu.json"} {
+				if !strings.HasSuffix(path, tfExt) {
+					continue
+				}
+				if _, shadowed := files[strings.TrimSuffix(path, tfExt)+tofuExt]; shadowed {
+					delete(files, path)
+				}
+			}
+		}
+		for _, duplicate := range module.FindDuplicates(files) {
+			log.Printf("Error: %s", duplicate)
+			found = true
+		}
+	}
+	return found
+}
+
+// isModuleConfigFile reports whether the file at path is part of the
+// configuration of a module: a .tf or .tofu file, in native or JSON syntax.
+func isModuleConfigFile(path string) bool {
+	for _, ext := range []string{".tf", ".tofu", ".tf.json", ".tofu.json"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subcommands returns the subcommands of tfsort. A path with the name of one of
+// them, such as a directory named merge, runs the subcommand instead; it is
+// sorted when written as ./merge or given after "--".
+func Subcommands() []*cli.Command {
+	return []*cli.Command{SplitCommand(), MergeCommand(), LintCommand()}
+}
+
+// isSubcommand reports whether arg names a subcommand of tfsort.
+func isSubcommand(arg string) bool {
+	for _, command := range Subcommands() {
+		if command.HasName(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeArgs rewrites the -0 flag to --null. The command line parser only
+// accepts short flags that start with a letter, and would take -0 for an
+// argument. Arguments after "--" are left as-is, except that a first one
+// naming a subcommand is prefixed with ./: the parser drops "--" and would
+// still run the subcommand.
+func NormalizeArgs(args []string) []string {
+	normalized := make([]string, len(args))
+	copy(normalized, args)
+	for i, arg := range normalized {
+		if arg == "--" {
+			if i+1 < len(normalized) && isSubcommand(normalized[i+1]) {
+				normalized[i+1] = "./" + normalized[i+1]
+			}
+			break
+		}
+		if arg == "-0" {
+			normalized[i] = "--null"
+		}
+	}
+	return normalized
+}
+
+// finishRun writes the report, if any, and the summary statistics, if
+// requested. The summary and the report both count the skipped inputs, of
+// which the first reported are already in the report. A reporter that takes
+// the summary includes it in its report; otherwise the summary is printed as
+// a table to stderr.
+func finishRun(reporter Reporter, summary *runSummary, skipped []skippedInput, reported int) error {
+	if summary != nil {
+		for _, input := range skipped {
+			summary.add(input.Path, report.StatusSkipped, nil, nil)
+		}
+		result := summary.finish()
+		if r, ok := reporter.(summaryReporter); ok {
+			r.SetSummary(result)
+		} else if err := writeSummaryTable(os.Stderr, result); err != nil {
+			return cli.Exit(fmt.Sprintf("Error: failed to write summary: %v", err), 2)
+		}
+	}
+	if reporter != nil {
+		return writeReport(reporter, skipped[reported:])
+	}
+	return nil
+}
+
+// addSkipped adds a skipped input to the report.
+func addSkipped(reporter Reporter, input skippedInput) {
+	reporter.Add(report.File{Path: input.Path, Status: report.StatusSkipped, Reason: input.Reason}, nil, nil, nil)
+}
+
+// writeReport adds the remaining skipped inputs to the report and writes it to
+// stdout.
+func writeReport(reporter Reporter, skipped []skippedInput) error {
+	for _, input := range skipped {
+		addSkipped(reporter, input)
+	}
+	if err := reporter.Write(os.Stdout); err != nil {
+		return cli.Exit(fmt.Sprintf("Error: failed to write report: %v", err), 2)
+	}
+	return nil
+}
+
+// listPath prints a path listed by --list, followed by separator.
+func listPath(path, separator string) {
+	fmt.Print(path + separator)
+}
 
-	return sources, nil
+// isOutputToTerminal checks if stdout is a terminal, so that colors can be used.
+var isOutputToTerminal = func() bool {
+	fileInfo, _ := os.Stdout.Stat()
+	return fileInfo != nil && (fileInfo.Mode()&os.ModeCharDevice) != 0
 }
 
 // isInputFromPipe checks if the program is receiving input from a pipe.
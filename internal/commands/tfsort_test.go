@@ -3,13 +3,17 @@ package commands
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings" // For comparing output, if needed for more complex stdout checks
 	"testing"
 
+	"github.com/tjun/tfsort/internal/report"
+	"github.com/tjun/tfsort/internal/sorter"
 	// urfave/cli is needed to construct the app for testing TfsortAction
 	"github.com/urfave/cli/v3"
 )
@@ -50,16 +54,17 @@ func setupTestFiles(t *testing.T, structure map[string]string) (string, func())
 	return tmpDir, cleanup
 }
 
-func TestProcessInputs(t *testing.T) {
+func TestCollectInputs(t *testing.T) {
 	// --- Test Cases Definition ---
 	testCases := []struct {
-		name        string
-		setup       map[string]string
-		args        []string
-		recursive   bool
-		mockStdin   string
-		wantSources []InputSource
-		wantErr     bool
+		name          string
+		setup         map[string]string
+		args          []string
+		recursive     bool
+		followModules bool
+		mockStdin     string
+		wantSources   []InputSource
+		wantErr       bool
 	}{
 		{
 			name:        "no args, no stdin pipe",
@@ -110,6 +115,139 @@ func TestProcessInputs(t *testing.T) {
 				{Path: filepath.Join("subdir", "nested", "vars.tf"), Content: []byte("variable {}")},
 			},
 		},
+		{
+			name: "directory with recursive picks up tfvars",
+			setup: map[string]string{
+				"env/main.tf":         "resource {}",
+				"env/prod.tfvars":     "a = 1",
+				"env/dev.auto.tfvars": "b = 2",
+				"env/vars.tfvars.bak": "c = 3",
+			},
+			args:      []string{"env"},
+			recursive: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("env", "dev.auto.tfvars"), Content: []byte("b = 2")},
+				{Path: filepath.Join("env", "main.tf"), Content: []byte("resource {}")},
+				{Path: filepath.Join("env", "prod.tfvars"), Content: []byte("a = 1")},
+			},
+		},
+		{
+			name: "directory with recursive picks up JSON syntax",
+			setup: map[string]string{
+				"env/main.tf.json":     "{}",
+				"env/prod.tfvars.json": "{}",
+				"env/package.json":     "{}",
+			},
+			args:      []string{"env"},
+			recursive: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("env", "main.tf.json"), Content: []byte("{}")},
+				{Path: filepath.Join("env", "prod.tfvars.json"), Content: []byte("{}")},
+			},
+		},
+		{
+			name: "directory with recursive picks up OpenTofu files",
+			setup: map[string]string{
+				"env/main.tf":         "resource {}",
+				"env/main.tofu":       "resource {}",
+				"env/extra.tofu.json": "{}",
+			},
+			args:      []string{"env"},
+			recursive: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("env", "extra.tofu.json"), Content: []byte("{}")},
+				{Path: filepath.Join("env", "main.tf"), Content: []byte("resource {}")},
+				{Path: filepath.Join("env", "main.tofu"), Content: []byte("resource {}")},
+			},
+		},
+		{
+			name: "directory with recursive picks up test files",
+			setup: map[string]string{
+				"tests/main.tftest.hcl": "run \"a\" {}",
+				"tests/aws.tfmock.hcl":  "mock_resource \"a\" {}",
+				"tests/other.hcl":       "a = 1",
+			},
+			args:      []string{"tests"},
+			recursive: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("tests", "aws.tfmock.hcl"), Content: []byte("mock_resource \"a\" {}")},
+				{Path: filepath.Join("tests", "main.tftest.hcl"), Content: []byte("run \"a\" {}")},
+			},
+		},
+		{
+			name: "directory with recursive picks up terragrunt files",
+			setup: map[string]string{
+				"live/app/terragrunt.hcl": "inputs = {}",
+				"live/root.hcl":           "locals {}",
+			},
+			args:      []string{"live"},
+			recursive: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("live", "app", "terragrunt.hcl"), Content: []byte("inputs = {}")},
+			},
+		},
+		{
+			name: "directory with recursive picks up stack files",
+			setup: map[string]string{
+				"stack/components.tfcomponent.hcl": "component \"a\" {}",
+				"stack/deployments.tfdeploy.hcl":   "deployment \"a\" {}",
+				"stack/.terraform.lock.hcl":        "provider {}",
+			},
+			args:      []string{"stack"},
+			recursive: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("stack", "components.tfcomponent.hcl"), Content: []byte("component \"a\" {}")},
+				{Path: filepath.Join("stack", "deployments.tfdeploy.hcl"), Content: []byte("deployment \"a\" {}")},
+			},
+		},
+		{
+			name: "follow-modules visits local module sources only",
+			setup: map[string]string{
+				"root/main.tf":                        "module \"vpc\" {\n  source = \"./modules/vpc\"\n}\n\nmodule \"s3\" {\n  source = \"terraform-aws-modules/s3-bucket/aws\"\n}\n",
+				"root/modules/vpc/main.tf":            "module \"subnet\" {\n  source = \"../subnet\"\n}\n",
+				"root/modules/subnet/main.tf":         "resource {}",
+				"root/modules/unused/main.tf":         "resource {}",
+				"root/.terraform/modules/s3/main.tf":  "resource {}",
+				"root/tests/fixtures/example/main.tf": "resource {}",
+			},
+			args:          []string{"root"},
+			followModules: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("root", "main.tf"), Content: []byte("module \"vpc\" {\n  source = \"./modules/vpc\"\n}\n\nmodule \"s3\" {\n  source = \"terraform-aws-modules/s3-bucket/aws\"\n}\n")},
+				{Path: filepath.Join("root", "modules", "vpc", "main.tf"), Content: []byte("module \"subnet\" {\n  source = \"../subnet\"\n}\n")},
+				{Path: filepath.Join("root", "modules", "subnet", "main.tf"), Content: []byte("resource {}")},
+			},
+		},
+		{
+			name: "follow-modules skips missing module directories",
+			setup: map[string]string{
+				"root/main.tf":             "module \"gone\" {\n  source = \"./missing\"\n}\n\nmodule \"vpc\" {\n  source = \"./modules/vpc\"\n}\n",
+				"root/modules/vpc/main.tf": "resource {}",
+			},
+			args:          []string{"root"},
+			followModules: true,
+			wantSources: []InputSource{
+				{Path: filepath.Join("root", "main.tf"), Content: []byte("module \"gone\" {\n  source = \"./missing\"\n}\n\nmodule \"vpc\" {\n  source = \"./modules/vpc\"\n}\n")},
+				{Path: filepath.Join("root", "modules", "vpc", "main.tf"), Content: []byte("resource {}")},
+			},
+		},
+		{
+			name: "follow-modules detects cycles",
+			setup: map[string]string{
+				"a/main.tf": "module \"b\" {\n  source = \"../b\"\n}\n",
+				"b/main.tf": "module \"a\" {\n  source = \"../a\"\n}\n",
+			},
+			args:          []string{"a"},
+			followModules: true,
+			wantErr:       true,
+		},
+		{
+			name:        "tfvars file",
+			setup:       map[string]string{"terraform.tfvars": "a = 1"},
+			args:        []string{"terraform.tfvars"},
+			recursive:   false,
+			wantSources: []InputSource{{Path: "terraform.tfvars", Content: []byte("a = 1")}},
+		},
 		{
 			name: "root dir recursive",
 			setup: map[string]string{
@@ -252,16 +390,16 @@ func TestProcessInputs(t *testing.T) {
 			isInputFromPipe = func() bool { return simulatedIsPipe }     // Override with test value
 			defer func() { isInputFromPipe = originalIsInputFromPipe }() // Restore original
 
-			gotSources, err := processInputs(adjustedArgs, tc.recursive)
+			gotSources, _, err := collectInputs(adjustedArgs, tc.recursive, tc.followModules, sorter.NewProfileSet())
 
 			if tc.wantErr {
 				if err == nil {
-					t.Errorf("processInputs() error = nil, wantErr %v", tc.wantErr)
+					t.Errorf("collectInputs() error = nil, wantErr %v", tc.wantErr)
 				}
 				return // Error expected and occurred (or not), end test case here
 			}
 			if err != nil {
-				t.Fatalf("processInputs() unexpected error = %v", err)
+				t.Fatalf("collectInputs() unexpected error = %v", err)
 			}
 
 			// Normalize paths for comparison if needed, but current implementation should handle it.
@@ -272,7 +410,7 @@ func TestProcessInputs(t *testing.T) {
 			}
 
 			if !equalInputSources(gotSources, tc.wantSources) {
-				t.Errorf("processInputs() gotSources = %v, want %v", gotSources, tc.wantSources)
+				t.Errorf("collectInputs() gotSources = %v, want %v", gotSources, tc.wantSources)
 			}
 		})
 	}
@@ -384,15 +522,271 @@ func TestTfsortActionOutputModes(t *testing.T) {
 			name:         "stdin to stdout",
 			args:         []string{}, // No file args, implies stdin
 			mockStdin:    "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
-			wantStdout:   "Reading from stdin...\nvariable \"a\" \"a\" {}\n\nresource \"b\" \"b\" {}\n",
+			wantStdout:   "variable \"a\" \"a\" {}\n\nresource \"b\" \"b\" {}\n",
 			wantExitCode: 0,
 		},
 		{
 			name:         "stdin with in-place flag (should warn and write to stdout)",
 			args:         []string{"-i"},
 			mockStdin:    "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
-			wantStdout:   "Reading from stdin...\nvariable \"a\" \"a\" {}\n\nresource \"b\" \"b\" {}\n", // Also check log for warning later
-			wantExitCode: 0,                                                                             // Expect 0 as it falls back to stdout
+			wantStdout:   "variable \"a\" \"a\" {}\n\nresource \"b\" \"b\" {}\n", // Also check log for warning later
+			wantExitCode: 0,                                                      // Expect 0 as it falls back to stdout
+		},
+		{
+			name:                "invalid sort-locals value",
+			setup:               map[string]string{"locals.tf": "locals {\n  b = 1\n  a = 2\n}\n"},
+			args:                []string{"--sort-locals=random", "locals.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid locals order",
+		},
+		{
+			name:         "sort-locals alphabetical",
+			setup:        map[string]string{"locals.tf": "locals {\n  b = 1\n  a = 2\n}\n"},
+			args:         []string{"--sort-locals=alphabetical", "locals.tf"},
+			wantStdout:   "locals {\n  a = 2\n  b = 1\n}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "tfvars sorted",
+			setup:        map[string]string{"prod.tfvars": "zones = [\"b\", \"a\"]\n# Region\nregion = \"us-east-1\"\n"},
+			args:         []string{"prod.tfvars"},
+			wantStdout:   "# Region\nregion = \"us-east-1\"\nzones  = [\"a\", \"b\"]\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "tfvars original order with no-sort-tfvars",
+			setup:        map[string]string{"prod.tfvars": "zones = [\"b\", \"a\"]\nregion = \"us-east-1\"\n"},
+			args:         []string{"--no-sort-tfvars", "prod.tfvars"},
+			wantStdout:   "zones  = [\"a\", \"b\"]\nregion = \"us-east-1\"\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "tf.json sorted",
+			setup:        map[string]string{"main.tf.json": `{"resource": {"b": {"x": {}}, "a": {"x": {}}}, "variable": {"v": {}}}`},
+			args:         []string{"main.tf.json"},
+			wantStdout:   "{\n  \"variable\": {\n    \"v\": {}\n  },\n  \"resource\": {\n    \"a\": {\n      \"x\": {}\n    },\n    \"b\": {\n      \"x\": {}\n    }\n  }\n}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "tfvars.json sorted",
+			setup:        map[string]string{"prod.tfvars.json": `{"zones": ["b", "a"], "region": "us-east-1"}`},
+			args:         []string{"prod.tfvars.json"},
+			wantStdout:   "{\n  \"region\": \"us-east-1\",\n  \"zones\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:                "invalid tf.json",
+			setup:               map[string]string{"main.tf.json": `{"resource": `},
+			args:                []string{"main.tf.json"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "Encountered errors during processing",
+		},
+		{
+			name:         "tofu file uses OpenTofu block order",
+			setup:        map[string]string{"main.tofu": "terraform {}\nvariable \"a\" {}\n"},
+			args:         []string{"main.tofu"},
+			wantStdout:   "variable \"a\" {}\n\nterraform {}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "dialect flag overrides file extension",
+			setup:        map[string]string{"main.tf": "terraform {}\nvariable \"a\" {}\n"},
+			args:         []string{"--dialect=opentofu", "main.tf"},
+			wantStdout:   "variable \"a\" {}\n\nterraform {}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:                "invalid dialect",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--dialect=hcl2", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "unknown dialect",
+		},
+		{
+			name: "check-duplicates reports blocks defined in two files",
+			setup: map[string]string{
+				"main.tf":      "variable \"region\" {}\n",
+				"variables.tf": "variable \"region\" {}\n",
+			},
+			args:                []string{"--check-duplicates", "--dry-run", "main.tf", "variables.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "Encountered errors during processing",
+		},
+		{
+			name: "check-duplicates reports blocks defined in native and JSON syntax",
+			setup: map[string]string{
+				"main.tf":      "variable \"region\" {}\n",
+				"main.tf.json": "{\n  \"variable\": {\n    \"region\": {}\n  }\n}\n",
+			},
+			args:                []string{"--check-duplicates", "--dry-run", "main.tf", "main.tf.json"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "Encountered errors during processing",
+		},
+		{
+			name: "check-duplicates ignores JSON files replaced by OpenTofu files",
+			setup: map[string]string{
+				"main.tf.json":   "{\n  \"variable\": {\n    \"region\": {}\n  }\n}\n",
+				"main.tofu.json": "{\n  \"variable\": {\n    \"region\": {}\n  }\n}\n",
+			},
+			args:         []string{"--check-duplicates", "--dry-run", "main.tf.json", "main.tofu.json"},
+			wantExitCode: 0,
+		},
+		{
+			name: "check-duplicates ignores Terraform files replaced by OpenTofu files",
+			setup: map[string]string{
+				"main.tf":   "variable \"region\" {}\n",
+				"main.tofu": "variable \"region\" {}\n",
+			},
+			args:         []string{"--check-duplicates", "--dry-run", "main.tf", "main.tofu"},
+			wantExitCode: 0,
+		},
+		{
+			name: "check-duplicates ignores override files",
+			setup: map[string]string{
+				"main.tf":          "variable \"region\" {}\n",
+				"main_override.tf": "variable \"region\" {\n  default = \"us-east-1\"\n}\n",
+			},
+			args:         []string{"--check-duplicates", "--dry-run", "main.tf", "main_override.tf"},
+			wantExitCode: 0,
+		},
+		{
+			name: "config file declares a custom profile",
+			setup: map[string]string{
+				"tfsort.hcl":    "profile \"nomad\" {\n  file_patterns = [\"*.nomad.hcl\"]\n  block_order   = [\"variable\", \"job\"]\n}\n",
+				"web.nomad.hcl": "job \"web\" {\n  datacenters = [\"b\", \"a\"]\n}\nvariable \"image\" {}\n",
+			},
+			args:         []string{"--config", "tfsort.hcl", "web.nomad.hcl"},
+			wantStdout:   "variable \"image\" {}\n\njob \"web\" {\n  datacenters = [\"b\", \"a\"]\n}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:                "invalid config file",
+			setup:               map[string]string{"tfsort.hcl": "profile \"x\" {\n  extends = \"chef\"\n}\n", "main.tf": "terraform {}\n"},
+			args:                []string{"--config", "tfsort.hcl", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "unknown dialect \"chef\"",
+		},
+		{
+			name:      "diff from stdin",
+			args:      []string{"--diff"},
+			mockStdin: "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
+			wantStdout: "--- -\n+++ -\n@@ -1,2 +1,3 @@\n" +
+				"-resource \"b\" \"b\" {}\n variable \"a\" \"a\" {}\n+\n+resource \"b\" \"b\" {}\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "list from stdin",
+			args:         []string{"--list"},
+			mockStdin:    "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
+			wantStdout:   "<stdin>\n",
+			wantExitCode: 0,
+		},
+		{
+			name:         "NUL-separated list from stdin",
+			args:         []string{"--list", "--null"},
+			mockStdin:    "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
+			wantStdout:   "<stdin>\x00",
+			wantExitCode: 0,
+		},
+		{
+			name:      "json report from stdin",
+			args:      []string{"--format", "json", "--dry-run"},
+			mockStdin: "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
+			wantStdout: `{
+  "files": [
+    {
+      "path": "<stdin>",
+      "status": "changed",
+      "changes": [
+        {
+          "kind": "block_moved",
+          "address": "variable.a.a",
+          "name": "variable.a.a",
+          "old_index": 1,
+          "new_index": 0,
+          "before": "resource.b.b"
+        }
+      ]
+    }
+  ]
+}
+`,
+			wantExitCode: 1,
+		},
+		{
+			name:                "json report with diff",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--format", "json", "--diff", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "--format json cannot be combined with --diff or --list",
+		},
+		{
+			name:         "sarif report with dry-run changes detected",
+			setup:        map[string]string{"unsorted.tf": "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n"},
+			args:         []string{"--format", "sarif", "--dry-run", "unsorted.tf"},
+			wantExitCode: 1,
+		},
+		{
+			name:                "sarif report with list",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--format", "sarif", "-l", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "--format sarif cannot be combined with --diff or --list",
+		},
+		{
+			name:         "github reporter from stdin",
+			args:         []string{"--reporter", "github", "--dry-run"},
+			mockStdin:    "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n",
+			wantStdout:   "::warning file=<stdin>,line=2,endLine=2,col=1,endColumn=20,title=tfsort block-order::variable.a.a is out of block type order; it belongs before resource.b.b.\n",
+			wantExitCode: 1,
+		},
+		{
+			name:                "invalid reporter",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--reporter", "jenkins", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid reporter \"jenkins\"",
+		},
+		{
+			name:                "reporter with diff",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--reporter", "junit", "--diff", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "--reporter junit cannot be combined with --diff or --list",
+		},
+		{
+			name:                "invalid format",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--format", "yaml", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid format \"yaml\"",
+		},
+		{
+			name:         "diff with dry-run changes detected",
+			setup:        map[string]string{"unsorted.tf": "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n"},
+			args:         []string{"--diff", "--dry-run", "--color", "never", "unsorted.tf"},
+			wantExitCode: 1,
+		},
+		{
+			name:            "diff with in-place writes the file",
+			setup:           map[string]string{"unsorted.tf": "resource \"b\" \"b\" {}\nvariable \"a\" \"a\" {}\n"},
+			args:            []string{"--diff", "-i", "unsorted.tf"},
+			wantFileContent: map[string]string{"unsorted.tf": "variable \"a\" \"a\" {}\n\nresource \"b\" \"b\" {}\n"},
+			wantExitCode:    0,
+		},
+		{
+			name:                "invalid color mode",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--diff", "--color", "sometimes", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid color mode \"sometimes\"",
+		},
+		{
+			name:                "negative diff context",
+			setup:               map[string]string{"main.tf": "terraform {}\n"},
+			args:                []string{"--diff", "--diff-context", "-1", "main.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "invalid diff context -1",
 		},
 		{
 			name:         "stdin with dry-run changes detected",
@@ -541,3 +935,247 @@ func TestTfsortActionOutputModes(t *testing.T) {
 		})
 	}
 }
+
+func TestTfsortActionList(t *testing.T) {
+	files := map[string]string{
+		"sorted.tf":        "variable \"a\" {}\n",
+		"unsorted.tf":      "resource \"b\" \"b\" {}\nvariable \"a\" {}\n",
+		"vars/unsorted.tf": "output \"b\" {\n  value = 1\n}\nvariable \"a\" {}\n",
+	}
+
+	testCases := []struct {
+		name                string
+		args                []string
+		wantStdout          string
+		wantFiles           map[string]string
+		wantExitCode        int
+		wantErrMsgSubstring string
+	}{
+		{
+			name:       "list files needing changes",
+			args:       []string{"-r", "-l", "."},
+			wantStdout: "unsorted.tf\nvars/unsorted.tf\n",
+			wantFiles:  map[string]string{"unsorted.tf": files["unsorted.tf"]},
+		},
+		{
+			name:       "NUL-separated list",
+			args:       []string{"-r", "-l", "-0", "."},
+			wantStdout: "unsorted.tf\x00vars/unsorted.tf\x00",
+		},
+		{
+			name:         "list with dry-run",
+			args:         []string{"--list", "--dry-run", "sorted.tf", "unsorted.tf"},
+			wantStdout:   "unsorted.tf\n",
+			wantExitCode: 1,
+		},
+		{
+			name:       "list with in-place lists rewritten files",
+			args:       []string{"-l", "-i", "sorted.tf", "unsorted.tf"},
+			wantStdout: "unsorted.tf\n",
+			wantFiles: map[string]string{
+				"sorted.tf":   files["sorted.tf"],
+				"unsorted.tf": "variable \"a\" {}\n\nresource \"b\" \"b\" {}\n",
+			},
+		},
+		{
+			name:                "NUL separator without list",
+			args:                []string{"-0", "unsorted.tf"},
+			wantExitCode:        2,
+			wantErrMsgSubstring: "-0/--null can only be used with --list",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, cleanup := setupTestFiles(t, files)
+			defer cleanup()
+			t.Chdir(tmpDir)
+			isInputFromPipe = func() bool { return false }
+
+			app := &cli.Command{
+				Name:   "tfsort-test-app",
+				Flags:  GetFlags(),
+				Action: TfsortAction,
+				ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+					// Prevent os.Exit during tests
+				},
+			}
+
+			var actionErr error
+			stdout := captureOutput(t, func() {
+				actionErr = app.Run(context.Background(), NormalizeArgs(append([]string{app.Name}, tc.args...)))
+			})
+
+			exitCode := 0
+			if actionErr != nil {
+				exitCode = 2
+				if exitCoder, ok := actionErr.(cli.ExitCoder); ok {
+					exitCode = exitCoder.ExitCode()
+				}
+			}
+			if exitCode != tc.wantExitCode {
+				t.Errorf("exit code = %d, want %d (error: %v)", exitCode, tc.wantExitCode, actionErr)
+			}
+			if tc.wantErrMsgSubstring != "" && (actionErr == nil || !strings.Contains(actionErr.Error(), tc.wantErrMsgSubstring)) {
+				t.Errorf("error = %v, want error containing %q", actionErr, tc.wantErrMsgSubstring)
+			}
+			if stdout != tc.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tc.wantStdout)
+			}
+			for name, want := range tc.wantFiles {
+				got, err := os.ReadFile(filepath.Join(tmpDir, name))
+				if err != nil {
+					t.Fatalf("Failed to read %s: %v", name, err)
+				}
+				if string(got) != want {
+					t.Errorf("%s content =\n%s\nwant:\n%s", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTfsortActionReport(t *testing.T) {
+	tmpDir, cleanup := setupTestFiles(t, map[string]string{
+		"broken.tf":   "variable \"a\" {\n",
+		"empty.tf":    "",
+		"notes.txt":   "text",
+		"sorted.tf":   "variable \"a\" {}\n",
+		"unsorted.tf": "resource \"b\" \"b\" {}\nvariable \"a\" {}\n",
+	})
+	defer cleanup()
+	t.Chdir(tmpDir)
+	isInputFromPipe = func() bool { return false }
+
+	app := &cli.Command{
+		Name:   "tfsort-test-app",
+		Flags:  GetFlags(),
+		Action: TfsortAction,
+		ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+			// Prevent os.Exit during tests
+		},
+	}
+
+	var actionErr error
+	stdout := captureOutput(t, func() {
+		actionErr = app.Run(context.Background(), []string{app.Name, "--format", "json", "-i", "broken.tf", "empty.tf", "notes.txt", "sorted.tf", "unsorted.tf"})
+	})
+	if exitCoder, ok := actionErr.(cli.ExitCoder); !ok || exitCoder.ExitCode() != 2 {
+		t.Errorf("error = %v, want exit code 2", actionErr)
+	}
+
+	var got report.Report
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout)
+	}
+	var paths []string
+	statuses := make(map[string]report.Status)
+	for _, file := range got.Files {
+		paths = append(paths, file.Path)
+		statuses[file.Path] = file.Status
+	}
+	wantStatuses := map[string]report.Status{
+		"broken.tf":   report.StatusError,
+		"empty.tf":    report.StatusSkipped,
+		"notes.txt":   report.StatusSkipped,
+		"sorted.tf":   report.StatusUnchanged,
+		"unsorted.tf": report.StatusChanged,
+	}
+	if !reflect.DeepEqual(statuses, wantStatuses) {
+		t.Errorf("statuses = %v, want %v", statuses, wantStatuses)
+	}
+	// Skipped inputs are listed in the order they were given.
+	if wantPaths := []string{"broken.tf", "empty.tf", "notes.txt", "sorted.tf", "unsorted.tf"}; !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("paths = %v, want %v", paths, wantPaths)
+	}
+	for _, file := range got.Files {
+		if file.Path == "broken.tf" && (len(file.Diagnostics) != 1 || file.Diagnostics[0].Range == nil || file.Diagnostics[0].Range.Start.Line != 1) {
+			t.Errorf("broken.tf diagnostics = %+v, want one diagnostic on line 1", file.Diagnostics)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "unsorted.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read unsorted.tf: %v", err)
+	}
+	if want := "variable \"a\" {}\n\nresource \"b\" \"b\" {}\n"; string(content) != want {
+		t.Errorf("unsorted.tf content = %q, want %q", content, want)
+	}
+}
+
+func TestNormalizeArgs(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "short NUL flag",
+			args: []string{"tfsort", "-l", "-0", "."},
+			want: []string{"tfsort", "-l", "--null", "."},
+		},
+		{
+			name: "arguments after double dash",
+			args: []string{"tfsort", "-l", "--", "-0"},
+			want: []string{"tfsort", "-l", "--", "-0"},
+		},
+		{
+			name: "path named like a subcommand after double dash",
+			args: []string{"tfsort", "-l", "--", "merge", "lint"},
+			want: []string{"tfsort", "-l", "--", "./merge", "lint"},
+		},
+		{
+			name: "subcommand",
+			args: []string{"tfsort", "merge", "--", "split"},
+			want: []string{"tfsort", "merge", "--", "./split"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NormalizeArgs(tc.args); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("NormalizeArgs() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPathNamedLikeSubcommand(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{name: "after double dash", args: []string{"-l", "-r", "--", "merge"}},
+		{name: "relative path", args: []string{"-l", "-r", "./merge"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, cleanup := setupTestFiles(t, map[string]string{
+				"merge/main.tf": "variable \"b\" {}\n\nterraform {}\n",
+			})
+			defer cleanup()
+			t.Chdir(tmpDir)
+
+			app := &cli.Command{
+				Name:     "tfsort",
+				Flags:    GetFlags(),
+				Action:   TfsortAction,
+				Commands: Subcommands(),
+				ExitErrHandler: func(ctx context.Context, cmd *cli.Command, err error) {
+					// Prevent os.Exit during tests
+				},
+			}
+			var runErr error
+			stdout := captureOutput(t, func() {
+				runErr = app.Run(context.Background(), NormalizeArgs(append([]string{"tfsort"}, tc.args...)))
+			})
+			if runErr != nil {
+				t.Fatalf("Run() unexpected error = %v", runErr)
+			}
+			if want := filepath.Join("merge", "main.tf") + "\n"; stdout != want {
+				t.Errorf("stdout = %q, want %q, the directory sorted rather than merged", stdout, want)
+			}
+		})
+	}
+}
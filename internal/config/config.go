@@ -0,0 +1,173 @@
+// Package config loads the tfsort configuration file, which declares custom
+// dialect profiles and maps file globs to profiles.
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// DefaultFile is the configuration file loaded from the working directory when
+// no file is given on the command line.
+const DefaultFile = ".tfsort.hcl"
+
+// Config is the content of a configuration file.
+//
+//	profile "nomad" {
+//	  extends       = "generic"
+//	  file_patterns = ["*.nomad.hcl"]
+//	  block_order   = ["job"]
+//	}
+//
+//	file "root.hcl" {
+//	  profile = "terragrunt"
+//	}
+//
+//	lint {
+//	  rules = {
+//	    "list-order" = "warn"
+//	  }
+//	}
+type Config struct {
+	Profiles []Profile `hcl:"profile,block"`
+	Files    []File    `hcl:"file,block"`
+	Lint     *Lint     `hcl:"lint,block"`
+}
+
+// Profile declares a dialect profile. Unset fields are inherited from the
+// profile named by Extends, or from the generic profile if it is not set,
+// except FilePatterns: a profile that extends terraform would otherwise take
+// over every .tf file, since user profiles are matched first.
+type Profile struct {
+	Name                   string              `hcl:"name,label"`
+	Extends                string              `hcl:"extends,optional"`
+	FilePatterns           []string            `hcl:"file_patterns,optional"`
+	BlockOrder             []string            `hcl:"block_order,optional"`
+	LabelSort              []string            `hcl:"label_sort,optional"`
+	MetaArguments          map[string][]string `hcl:"meta_arguments,optional"`
+	SortedAttributeBlocks  []string            `hcl:"sorted_attribute_blocks,optional"`
+	TrailingAttributes     []string            `hcl:"trailing_attributes,optional"`
+	SortedObjectAttributes []string            `hcl:"sorted_object_attributes,optional"`
+	ListScopes             *[]string           `hcl:"list_scopes,optional"`
+}
+
+// File maps the files whose base name matches Pattern to a profile.
+type File struct {
+	Pattern string `hcl:"pattern,label"`
+	Profile string `hcl:"profile"`
+}
+
+// Lint configures the lint command.
+type Lint struct {
+	// Rules maps rule IDs to their severity: off, warn or error.
+	Rules map[string]string `hcl:"rules,optional"`
+}
+
+// LintRules returns the rule severities of the lint block. A nil Config, or
+// one without a lint block, yields none.
+func (c *Config) LintRules() map[string]string {
+	if c == nil || c.Lint == nil {
+		return nil
+	}
+	return c.Lint.Rules
+}
+
+// Load reads and decodes the configuration file at path.
+func Load(path string) (*Config, error) {
+	file, diags := hclparse.NewParser().ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, diags)
+	}
+	return decode(file.Body, path)
+}
+
+// Parse decodes a configuration from src. filename is used in error messages.
+func Parse(src []byte, filename string) (*Config, error) {
+	file, diags := hclparse.NewParser().ParseHCL(src, filename)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse config %s: %v", filename, diags)
+	}
+	return decode(file.Body, filename)
+}
+
+// decode decodes body into a Config. Unknown arguments and blocks are errors.
+func decode(body hcl.Body, filename string) (*Config, error) {
+	var cfg Config
+	if diags := gohcl.DecodeBody(body, nil, &cfg); diags.HasErrors() {
+		return nil, fmt.Errorf("invalid config %s: %v", filename, diags)
+	}
+	return &cfg, nil
+}
+
+// ProfileSet returns the built-in profiles extended with the profiles and
+// file mappings of the configuration. A nil Config yields the built-ins.
+func (c *Config) ProfileSet() (*sorter.ProfileSet, error) {
+	profiles := sorter.NewProfileSet()
+	if c == nil {
+		return profiles, nil
+	}
+
+	for _, declared := range c.Profiles {
+		profile, err := declared.build(profiles)
+		if err != nil {
+			return nil, err
+		}
+		if err := profiles.Add(profile); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range c.Files {
+		if err := profiles.MapFiles(file.Pattern, file.Profile); err != nil {
+			return nil, fmt.Errorf("file %q: %w", file.Pattern, err)
+		}
+	}
+	return profiles, nil
+}
+
+// build turns the declaration into a sorter profile. Profiles named by Extends
+// are looked up in profiles, so a profile can extend built-in profiles and the
+// ones declared before it.
+func (p Profile) build(profiles *sorter.ProfileSet) (*sorter.Profile, error) {
+	base := sorter.GenericProfile
+	if p.Extends != "" {
+		var err error
+		base, err = profiles.Lookup(p.Extends)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+	}
+	profile := base.Clone(p.Name)
+	profile.FilePatterns = p.FilePatterns
+	if len(p.BlockOrder) > 0 {
+		profile.BlockOrder = make(map[string]int, len(p.BlockOrder))
+		for i, blockType := range p.BlockOrder {
+			profile.BlockOrder[blockType] = i + 1
+		}
+	}
+	if len(p.LabelSort) > 0 {
+		profile.LabelSortTypes = make(map[string]bool, len(p.LabelSort))
+		for _, blockType := range p.LabelSort {
+			profile.LabelSortTypes[blockType] = true
+		}
+	}
+	if len(p.MetaArguments) > 0 {
+		profile.MetaArguments = p.MetaArguments
+	}
+	if len(p.SortedAttributeBlocks) > 0 {
+		profile.SortedAttributeBlocks = p.SortedAttributeBlocks
+	}
+	if len(p.TrailingAttributes) > 0 {
+		profile.TrailingAttributes = p.TrailingAttributes
+	}
+	if len(p.SortedObjectAttributes) > 0 {
+		profile.SortedObjectAttributes = p.SortedObjectAttributes
+	}
+	if p.ListScopes != nil {
+		profile.ListScopes = append([]string{}, *p.ListScopes...)
+	}
+	return profile, nil
+}
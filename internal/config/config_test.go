@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+func TestProfileSet(t *testing.T) {
+	src := `
+profile "nomad" {
+  file_patterns = ["*.nomad.hcl", "*.nomad"]
+  block_order   = ["job", "variable"]
+  label_sort    = ["job"]
+  list_scopes   = ["job.datacenters"]
+
+  meta_arguments = {
+    task = ["driver"]
+  }
+}
+
+profile "live" {
+  extends            = "terragrunt"
+  block_order        = ["include", "locals", "dependency", "terraform"]
+  trailing_attributes = ["inputs", "prevent_destroy"]
+}
+
+file "root.hcl" {
+  profile = "live"
+}
+`
+	cfg, err := Parse([]byte(src), "test.hcl")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error = %v", err)
+	}
+	profiles, err := cfg.ProfileSet()
+	if err != nil {
+		t.Fatalf("ProfileSet() unexpected error = %v", err)
+	}
+
+	nomad, err := profiles.Lookup("nomad")
+	if err != nil {
+		t.Fatalf("Lookup(\"nomad\") unexpected error = %v", err)
+	}
+	if got := profiles.ForFile("jobs/web.nomad"); got != nomad {
+		t.Errorf("ForFile(\"jobs/web.nomad\") = %v, want the nomad profile", got)
+	}
+	if nomad.BlockOrder["job"] != 1 || nomad.BlockOrder["variable"] != 2 {
+		t.Errorf("nomad BlockOrder = %v, want job=1, variable=2", nomad.BlockOrder)
+	}
+	if !nomad.LabelSortTypes["job"] {
+		t.Errorf("nomad LabelSortTypes = %v, want job", nomad.LabelSortTypes)
+	}
+	if got := nomad.MetaArguments["task"]; len(got) != 1 || got[0] != "driver" {
+		t.Errorf("nomad MetaArguments = %v, want task=[driver]", nomad.MetaArguments)
+	}
+	if len(nomad.ListScopes) != 1 || nomad.ListScopes[0] != "job.datacenters" {
+		t.Errorf("nomad ListScopes = %v, want [job.datacenters]", nomad.ListScopes)
+	}
+
+	live, err := profiles.Lookup("live")
+	if err != nil {
+		t.Fatalf("Lookup(\"live\") unexpected error = %v", err)
+	}
+	if got := profiles.ForFile("live/root.hcl"); got != live {
+		t.Errorf("ForFile(\"live/root.hcl\") = %v, want the live profile", got)
+	}
+	// File patterns are not inherited, so terragrunt keeps its files.
+	if got := profiles.ForFile("live/prod/terragrunt.hcl"); got != sorter.TerragruntProfile {
+		t.Errorf("ForFile(\"live/prod/terragrunt.hcl\") = %v, want the terragrunt profile", got)
+	}
+	// Inherited from terragrunt
+	if len(live.ListScopes) != 1 || live.ListScopes[0] != "dependencies.paths" {
+		t.Errorf("live ListScopes = %v, want [dependencies.paths]", live.ListScopes)
+	}
+	if len(live.TrailingAttributes) != 2 {
+		t.Errorf("live TrailingAttributes = %v, want [inputs prevent_destroy]", live.TrailingAttributes)
+	}
+	if _, ok := sorter.TerragruntProfile.BlockOrder["remote_state"]; !ok {
+		t.Error("extending terragrunt changed the built-in profile")
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name:    "syntax error",
+			src:     `profile "x" {`,
+			wantErr: "failed to parse config",
+		},
+		{
+			name:    "unknown argument",
+			src:     `sort_everything = true`,
+			wantErr: "Unsupported argument",
+		},
+		{
+			name:    "unknown base profile",
+			src:     "profile \"x\" {\n  extends = \"chef\"\n}\n",
+			wantErr: `unknown dialect "chef"`,
+		},
+		{
+			name:    "duplicate profile",
+			src:     "profile \"x\" {}\nprofile \"x\" {}\n",
+			wantErr: `profile "x" is defined more than once`,
+		},
+		{
+			name:    "unknown mapped profile",
+			src:     "file \"*.hcl\" {\n  profile = \"chef\"\n}\n",
+			wantErr: `unknown dialect "chef"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tc.src), "test.hcl")
+			if err == nil {
+				_, err = cfg.ProfileSet()
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(path, []byte("file \"*.hcl\" {\n  profile = \"generic\"\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+	if len(cfg.Files) != 1 || cfg.Files[0].Profile != "generic" {
+		t.Errorf("Load() Files = %v, want one mapping to generic", cfg.Files)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.hcl")); err == nil {
+		t.Error("Load() of a missing file error = nil, want error")
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	cfg, err := Parse([]byte("lint {\n  rules = {\n    \"list-order\" = \"warn\"\n  }\n}\n"), "test.hcl")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error = %v", err)
+	}
+	if got := cfg.LintRules(); len(got) != 1 || got["list-order"] != "warn" {
+		t.Errorf("LintRules() = %v, want list-order=warn", got)
+	}
+	if got := (*Config)(nil).LintRules(); got != nil {
+		t.Errorf("LintRules() of a nil Config = %v, want nil", got)
+	}
+}
@@ -0,0 +1,283 @@
+// Package diff renders the changes between two versions of a file as a unified
+// diff that `git apply` and `patch -p1` accept. A diff of stdin has no file to
+// apply to and is for display only.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+// ANSI escape sequences used to color the diff.
+const (
+	colorBold  = "\x1b[1m"
+	colorCyan  = "\x1b[36m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
+
+// noNewlineMarker follows a line that does not end with a newline.
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+// Options controls how a diff is rendered.
+type Options struct {
+	// Context is the number of unchanged lines shown around each change.
+	Context int
+	// Color highlights headers, hunk ranges, and removed and added lines with
+	// ANSI escape sequences.
+	Color bool
+}
+
+// opKind is the kind of an edit operation.
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is a single line of an edit script. OldLine and NewLine are the indexes
+// of the line in the old and new versions, where it exists.
+type op struct {
+	Kind    opKind
+	OldLine int
+	NewLine int
+}
+
+// Unified returns the unified diff that turns old into new for the file at
+// path, named relative to the working directory with a/ and b/ prefixes as
+// written by git, or by its absolute path if it is outside the working
+// directory. It returns nil if the versions are equal.
+func Unified(path string, old, new []byte, options Options) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	oldLines := splitLines(old)
+	newLines := splitLines(new)
+	ops := editScript(oldLines, newLines)
+
+	oldName, newName := headerNames(path)
+	var b bytes.Buffer
+	writeLine(&b, options, colorBold, "--- "+oldName+"\n")
+	writeLine(&b, options, colorBold, "+++ "+newName+"\n")
+	for _, h := range hunks(ops, options.Context) {
+		writeHunk(&b, h, oldLines, newLines, options)
+	}
+	return b.Bytes()
+}
+
+// headerNames returns the names of the file at path in the headers of its
+// patch. The path is made relative to the working directory, with forward
+// slashes and the a/ and b/ prefixes, so that the patch applies there. A path
+// outside the working directory cannot be named that way, so it is given as
+// an absolute path without prefixes. Input from stdin, given as "<stdin>", is
+// named "-", as diff(1) names it.
+func headerNames(path string) (oldName, newName string) {
+	if path == "<stdin>" {
+		return "-", "-"
+	}
+	name := filepath.Clean(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		name = abs
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				name = rel
+			}
+		}
+	}
+	name = filepath.ToSlash(name)
+	if filepath.IsAbs(filepath.FromSlash(name)) {
+		return name, name
+	}
+	return "a/" + name, "b/" + name
+}
+
+// splitLines splits content into lines that keep their newline. A last line
+// without a newline is kept as-is.
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:i+1]))
+		content = content[i+1:]
+	}
+	return lines
+}
+
+// editScript returns the shortest edit script that turns a into b, using the
+// algorithm of Myers' "An O(ND) Difference Algorithm and Its Variations". The
+// trace keeps only the diagonals each step can reach, so it takes O(D²) memory
+// for D edits rather than O(D·(N+M)).
+func editScript(a, b []string) []op {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	// trace[d] holds the furthest x on the diagonals -(d-1) to d-1 before step d.
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		var reached []int
+		if d > 0 {
+			reached = append(reached, v[offset-d+1:offset+d]...)
+		}
+		trace = append(trace, reached)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Step down: insert from b
+			} else {
+				x = v[offset+k-1] + 1 // Step right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back from the end, following the steps recorded in the trace.
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		reached := trace[d]
+		at := func(k int) int { return reached[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{Kind: opEqual, OldLine: x, NewLine: y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{Kind: opInsert, OldLine: x, NewLine: y})
+		} else {
+			x--
+			ops = append(ops, op{Kind: opDelete, OldLine: x, NewLine: y})
+		}
+	}
+	// The first step starts with the lines both versions share.
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{Kind: opEqual, OldLine: x, NewLine: y})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunks groups the edit script into hunks of changes together with up to
+// context unchanged lines around them. Changes separated by at most twice the
+// context unchanged lines share a hunk, so that their context does not overlap
+// in separate hunks.
+func hunks(ops []op, context int) [][]op {
+	if context < 0 {
+		context = 0
+	}
+	var result [][]op
+	start, end := -1, -1
+	for i, o := range ops {
+		if o.Kind == opEqual {
+			continue
+		}
+		if start >= 0 && i-end > context {
+			result = append(result, ops[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = max(0, i-context)
+		}
+		end = min(len(ops), i+1+context)
+	}
+	if start >= 0 {
+		result = append(result, ops[start:end])
+	}
+	return result
+}
+
+// writeHunk writes the header and lines of a hunk.
+func writeHunk(b *bytes.Buffer, h []op, oldLines, newLines []string, options Options) {
+	oldStart, newStart := h[0].OldLine, h[0].NewLine
+	oldCount, newCount := 0, 0
+	for _, o := range h {
+		if o.Kind != opInsert {
+			oldCount++
+		}
+		if o.Kind != opDelete {
+			newCount++
+		}
+	}
+	writeLine(b, options, colorCyan, fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount)))
+
+	for _, o := range h {
+		switch o.Kind {
+		case opEqual:
+			writeContent(b, options, "", " ", oldLines[o.OldLine])
+		case opDelete:
+			writeContent(b, options, colorRed, "-", oldLines[o.OldLine])
+		case opInsert:
+			writeContent(b, options, colorGreen, "+", newLines[o.NewLine])
+		}
+	}
+}
+
+// hunkRange formats the line range of a hunk. The start is 1-based, or the
+// line before the hunk when it is empty.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// writeContent writes a line of a hunk with its prefix, followed by a marker
+// if the line does not end with a newline.
+func writeContent(b *bytes.Buffer, options Options, color, prefix, line string) {
+	if strings.HasSuffix(line, "\n") {
+		writeLine(b, options, color, prefix+line)
+		return
+	}
+	writeLine(b, options, color, prefix+line+"\n")
+	b.WriteString(noNewlineMarker)
+}
+
+// writeLine writes a line, wrapped in the color escape sequences if coloring is
+// enabled. The newline stays outside of the colored text.
+func writeLine(b *bytes.Buffer, options Options, color, line string) {
+	if !options.Color || color == "" {
+		b.WriteString(line)
+		return
+	}
+	b.WriteString(color + strings.TrimSuffix(line, "\n") + colorReset + "\n")
+}
@@ -0,0 +1,261 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		old     string
+		new     string
+		options Options
+		want    string
+	}{
+		{
+			name: "equal",
+			path: "main.tf",
+			old:  "a\n",
+			new:  "a\n",
+			want: "",
+		},
+		{
+			name: "moved block",
+			path: "./modules/vpc/main.tf",
+			old: `resource "b" "b" {}
+variable "a" {}
+`,
+			new: `variable "a" {}
+
+resource "b" "b" {}
+`,
+			options: Options{Context: DefaultContext},
+			want: `--- a/modules/vpc/main.tf
++++ b/modules/vpc/main.tf
+@@ -1,2 +1,3 @@
+-resource "b" "b" {}
+ variable "a" {}
++
++resource "b" "b" {}
+`,
+		},
+		{
+			name:    "separate hunks",
+			path:    "list.tf",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:     "0\n2\n3\n4\n5\n6\n7\n8\nnine\n",
+			options: Options{Context: 1},
+			want: `--- a/list.tf
++++ b/list.tf
+@@ -1,2 +1,2 @@
+-1
++0
+ 2
+@@ -8,2 +8,2 @@
+ 8
+-9
++nine
+`,
+		},
+		{
+			name:    "changes more than twice the context apart",
+			path:    "gap.tf",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:     "0\n2\n3\n4\n5\n6\n7\n8\nnine\n",
+			options: Options{Context: DefaultContext},
+			want: `--- a/gap.tf
++++ b/gap.tf
+@@ -1,4 +1,4 @@
+-1
++0
+ 2
+ 3
+ 4
+@@ -6,4 +6,4 @@
+ 6
+ 7
+ 8
+-9
++nine
+`,
+		},
+		{
+			name:    "changes twice the context apart",
+			path:    "gap.tf",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:     "0\n2\n3\n4\n5\n6\n7\neight\n",
+			options: Options{Context: DefaultContext},
+			want: `--- a/gap.tf
++++ b/gap.tf
+@@ -1,8 +1,8 @@
+-1
++0
+ 2
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++eight
+`,
+		},
+		{
+			name:    "zero context",
+			path:    "x.tf",
+			old:     "a\nb\nc\n",
+			new:     "a\nc\nd\n",
+			options: Options{Context: 0},
+			want: `--- a/x.tf
++++ b/x.tf
+@@ -2 +1,0 @@
+-b
+@@ -3,0 +3 @@
++d
+`,
+		},
+		{
+			name:    "missing newline at end of file",
+			path:    "x.tf",
+			old:     "a\nb",
+			new:     "a\nb\n",
+			options: Options{Context: DefaultContext},
+			want: `--- a/x.tf
++++ b/x.tf
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+		},
+		{
+			name:    "color",
+			path:    "x.tf",
+			old:     "a\nb\n",
+			new:     "a\nc\n",
+			options: Options{Context: DefaultContext, Color: true},
+			want: "\x1b[1m--- a/x.tf\x1b[0m\n" +
+				"\x1b[1m+++ b/x.tf\x1b[0m\n" +
+				"\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n" +
+				" a\n" +
+				"\x1b[31m-b\x1b[0m\n" +
+				"\x1b[32m+c\x1b[0m\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(Unified(tc.path, []byte(tc.old), []byte(tc.new), tc.options))
+			if got != tc.want {
+				t.Errorf("Unified() mismatch\nGot:\n%s\nWant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEditScript(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b string
+	}{
+		{name: "empty old", a: "", b: "abc"},
+		{name: "empty new", a: "abc", b: ""},
+		{name: "equal", a: "abc", b: "abc"},
+		{name: "moved line", a: "abcdef", b: "bcdefa"},
+		{name: "interleaved", a: "abcabba", b: "cbabac"},
+		{name: "disjoint", a: "abc", b: "xyz"},
+		{name: "long with few changes", a: strings.Repeat("ab", 200) + "x" + strings.Repeat("cd", 200), b: strings.Repeat("ab", 200) + "y" + strings.Repeat("cd", 200) + "z"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := strings.Split(tc.a, "")
+			b := strings.Split(tc.b, "")
+			ops := editScript(a, b)
+
+			var got []string
+			edits := 0
+			for _, o := range ops {
+				switch o.Kind {
+				case opEqual:
+					if a[o.OldLine] != b[o.NewLine] {
+						t.Fatalf("equal op pairs %q with %q", a[o.OldLine], b[o.NewLine])
+					}
+					got = append(got, a[o.OldLine])
+				case opInsert:
+					got = append(got, b[o.NewLine])
+					edits++
+				case opDelete:
+					edits++
+				}
+			}
+			if strings.Join(got, "") != tc.b {
+				t.Errorf("edit script builds %q, want %q", strings.Join(got, ""), tc.b)
+			}
+			if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+				t.Errorf("edit script has %d edits, want %d", edits, want)
+			}
+		})
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestHeaderNames(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir("sub", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir("sub")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := filepath.ToSlash(filepath.Dir(wd))
+
+	testCases := []struct {
+		path    string
+		oldName string
+		newName string
+	}{
+		{path: "main.tf", oldName: "a/main.tf", newName: "b/main.tf"},
+		{path: "./modules/vpc/main.tf", oldName: "a/modules/vpc/main.tf", newName: "b/modules/vpc/main.tf"},
+		{path: filepath.Join(wd, "modules", "x.tf"), oldName: "a/modules/x.tf", newName: "b/modules/x.tf"},
+		{path: "../x.tf", oldName: parent + "/x.tf", newName: parent + "/x.tf"},
+		{path: filepath.Join(filepath.Dir(wd), "x.tf"), oldName: parent + "/x.tf", newName: parent + "/x.tf"},
+		{path: "<stdin>", oldName: "-", newName: "-"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			oldName, newName := headerNames(tc.path)
+			if oldName != tc.oldName || newName != tc.newName {
+				t.Errorf("headerNames(%q) = %q, %q, want %q, %q", tc.path, oldName, newName, tc.oldName, tc.newName)
+			}
+		})
+	}
+}
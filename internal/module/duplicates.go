@@ -0,0 +1,262 @@
+// Package module analyzes Terraform modules as a whole, across the files of a
+// module directory.
+package module
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Definition is a place where a module defines an address.
+type Definition struct {
+	Filename string
+	Line     int
+}
+
+// String formats the definition as file:line.
+func (d Definition) String() string {
+	return fmt.Sprintf("%s:%d", d.Filename, d.Line)
+}
+
+// Duplicate is an address defined more than once within a module.
+type Duplicate struct {
+	Address     string // Such as resource.aws_s3_bucket.logs, local.name or required_providers.aws
+	Definitions []Definition
+}
+
+// String describes the duplicate with all of its definitions.
+func (d Duplicate) String() string {
+	places := make([]string, len(d.Definitions))
+	for i, definition := range d.Definitions {
+		places[i] = definition.String()
+	}
+	return fmt.Sprintf("%s is defined more than once: %s", d.Address, strings.Join(places, ", "))
+}
+
+// FindDuplicates indexes the addresses defined by the files of one module,
+// given by file name, and returns those defined more than once, ordered by
+// their first definition. It covers blocks such as resources and variables,
+// provider configurations by name and alias, locals keys and
+// required_providers entries. Files in native and JSON syntax are supported;
+// files that do not parse are skipped, as their errors are reported when they
+// are sorted.
+// Override files are skipped too, since they redefine addresses on purpose.
+func FindDuplicates(files map[string][]byte) []Duplicate {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if IsOverrideFile(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	index := make(map[string][]Definition)
+	var order []string
+	for _, name := range names {
+		defs, ok := fileDefinitions(name, files[name])
+		if !ok {
+			continue
+		}
+		for _, def := range defs {
+			if _, seen := index[def.address]; !seen {
+				order = append(order, def.address)
+			}
+			index[def.address] = append(index[def.address], Definition{Filename: name, Line: def.line})
+		}
+	}
+
+	var duplicates []Duplicate
+	for _, address := range order {
+		if defs := index[address]; len(defs) > 1 {
+			duplicates = append(duplicates, Duplicate{Address: address, Definitions: defs})
+		}
+	}
+	return duplicates
+}
+
+// IsOverrideFile reports whether the file at path is an override file, such as
+// override.tf, main_override.tf or main_override.tf.json, whose blocks Terraform
+// merges into the blocks of the same address in the other files of the module.
+func IsOverrideFile(path string) bool {
+	base := strings.TrimSuffix(filepath.Base(path), ".json")
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
+
+// addressDefinition is an address defined at a line of a file.
+type addressDefinition struct {
+	address string
+	line    int
+}
+
+// fileDefinitions parses the file named name, in JSON syntax if its name ends
+// in .json and in native syntax otherwise, and returns the addresses it
+// defines. It returns false if the file does not parse.
+func fileDefinitions(name string, content []byte) ([]addressDefinition, bool) {
+	if strings.HasSuffix(name, ".json") {
+		file, diags := hcljson.Parse(content, name)
+		if diags.HasErrors() {
+			return nil, false
+		}
+		return jsonDefinitions(file.Body), true
+	}
+	file, diags := hclsyntax.ParseConfig(content, name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+	return definitions(body), true
+}
+
+// definitions returns the addresses defined by the top-level blocks of body in
+// source order.
+func definitions(body *hclsyntax.Body) []addressDefinition {
+	var defs []addressDefinition
+	for _, block := range body.Blocks {
+		line := block.TypeRange.Start.Line
+		switch block.Type {
+		case "resource", "data", "module", "variable", "output", "check", "ephemeral":
+			defs = append(defs, addressDefinition{address: blockAddress(block), line: line})
+		case "provider":
+			address := blockAddress(block)
+			if alias := stringAttribute(block.Body, "alias"); alias != "" {
+				address += "." + alias
+			}
+			defs = append(defs, addressDefinition{address: address, line: line})
+		case "locals":
+			defs = append(defs, attributeDefinitions(block.Body, "local.")...)
+		case "terraform":
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "required_providers" {
+					defs = append(defs, attributeDefinitions(nested.Body, "required_providers.")...)
+				}
+			}
+		}
+	}
+	return defs
+}
+
+// jsonSchema is the schema of the top-level blocks that define addresses in a
+// file in JSON syntax, where the labels of a block are nested object keys.
+var jsonSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "ephemeral", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+		{Type: "check", LabelNames: []string{"name"}},
+		{Type: "provider", LabelNames: []string{"name"}},
+		{Type: "locals"},
+		{Type: "terraform"},
+	},
+}
+
+// jsonDefinitions returns the addresses defined by body, the body of a file in
+// JSON syntax, in source order. They are the addresses definitions returns for
+// the same configuration in native syntax.
+func jsonDefinitions(body hcl.Body) []addressDefinition {
+	var defs []addressDefinition
+	content, _, _ := body.PartialContent(jsonSchema)
+	for _, block := range content.Blocks {
+		address := strings.Join(append([]string{block.Type}, block.Labels...), ".")
+		line := block.DefRange.Start.Line
+		switch block.Type {
+		case "provider":
+			aliasContent, _, _ := block.Body.PartialContent(&hcl.BodySchema{
+				Attributes: []hcl.AttributeSchema{{Name: "alias"}},
+			})
+			if attr, ok := aliasContent.Attributes["alias"]; ok {
+				if alias := literalString(attr.Expr); alias != "" {
+					address += "." + alias
+				}
+			}
+			defs = append(defs, addressDefinition{address: address, line: line})
+		case "locals":
+			defs = append(defs, jsonAttributeDefinitions(block.Body, "local.")...)
+		case "terraform":
+			nested, _, _ := block.Body.PartialContent(&hcl.BodySchema{
+				Blocks: []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+			})
+			for _, providers := range nested.Blocks {
+				defs = append(defs, jsonAttributeDefinitions(providers.Body, "required_providers.")...)
+			}
+		default:
+			defs = append(defs, addressDefinition{address: address, line: line})
+		}
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		return defs[i].line < defs[j].line
+	})
+	return defs
+}
+
+// sortByLine sorts defs, collected from a map, by line, and the definitions on
+// the same line, such as the keys of a one-line JSON object, by address.
+func sortByLine(defs []addressDefinition) {
+	sort.SliceStable(defs, func(i, j int) bool {
+		if defs[i].line != defs[j].line {
+			return defs[i].line < defs[j].line
+		}
+		return defs[i].address < defs[j].address
+	})
+}
+
+// jsonAttributeDefinitions is attributeDefinitions for a body in JSON syntax.
+func jsonAttributeDefinitions(body hcl.Body, prefix string) []addressDefinition {
+	attrs, _ := body.JustAttributes()
+	var defs []addressDefinition
+	for name, attr := range attrs {
+		defs = append(defs, addressDefinition{address: prefix + name, line: attr.NameRange.Start.Line})
+	}
+	sortByLine(defs)
+	return defs
+}
+
+// attributeDefinitions returns an address for every attribute of body, made of
+// prefix and the attribute name, in source order.
+func attributeDefinitions(body *hclsyntax.Body, prefix string) []addressDefinition {
+	var defs []addressDefinition
+	for name, attr := range body.Attributes {
+		defs = append(defs, addressDefinition{address: prefix + name, line: attr.NameRange.Start.Line})
+	}
+	sortByLine(defs)
+	return defs
+}
+
+// blockAddress joins the type and labels of block with dots.
+func blockAddress(block *hclsyntax.Block) string {
+	return strings.Join(append([]string{block.Type}, block.Labels...), ".")
+}
+
+// stringAttribute returns the value of an attribute of body that holds a
+// literal string, or an empty string.
+func stringAttribute(body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	return literalString(attr.Expr)
+}
+
+// literalString returns the value of expr if it is a literal string, or an
+// empty string.
+func literalString(expr hcl.Expression) string {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.Type().Equals(cty.String) || value.IsNull() {
+		return ""
+	}
+	return value.AsString()
+}
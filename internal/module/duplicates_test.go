@@ -0,0 +1,203 @@
+package module
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "no duplicates",
+			files: map[string]string{
+				"main.tf": `resource "aws_s3_bucket" "logs" {}
+
+resource "aws_s3_bucket" "data" {}
+
+provider "aws" {}
+
+provider "aws" {
+  alias = "east"
+}
+`,
+				"variables.tf": "variable \"region\" {}\n",
+			},
+		},
+		{
+			name: "duplicate blocks across files",
+			files: map[string]string{
+				"main.tf": `variable "region" {}
+
+resource "aws_s3_bucket" "logs" {}
+`,
+				"s3.tf": `# Logs
+resource "aws_s3_bucket" "logs" {}
+`,
+				"variables.tf": `variable "name" {}
+variable "region" {}
+`,
+			},
+			want: []string{
+				"variable.region is defined more than once: main.tf:1, variables.tf:2",
+				"resource.aws_s3_bucket.logs is defined more than once: main.tf:3, s3.tf:2",
+			},
+		},
+		{
+			name: "duplicate provider configurations",
+			files: map[string]string{
+				"a.tf": "provider \"aws\" {\n  alias = \"east\"\n}\n",
+				"b.tf": "provider \"aws\" {\n  alias  = \"east\"\n  region = \"us-east-1\"\n}\n\nprovider \"aws\" {}\n",
+				"c.tf": "provider \"aws\" {}\n",
+			},
+			want: []string{
+				"provider.aws.east is defined more than once: a.tf:1, b.tf:1",
+				"provider.aws is defined more than once: b.tf:6, c.tf:1",
+			},
+		},
+		{
+			name: "duplicate locals and required providers",
+			files: map[string]string{
+				"locals.tf": `locals {
+  name = "a"
+  env  = "dev"
+}
+`,
+				"main.tf": `locals {
+  name = "b"
+}
+`,
+				"versions.tf": `terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+`,
+			},
+			want: []string{
+				"local.name is defined more than once: locals.tf:2, main.tf:2",
+				"required_providers.aws is defined more than once: versions.tf:3, versions.tf:11",
+			},
+		},
+		{
+			name: "duplicates across native and JSON syntax",
+			files: map[string]string{
+				"main.tf": `variable "x" {}
+
+provider "aws" {
+  alias = "east"
+}
+
+locals {
+  name = "a"
+}
+`,
+				"main.tf.json": `{
+  "variable": {
+    "x": {}
+  },
+  "provider": {
+    "aws": [
+      {"alias": "east"},
+      {}
+    ]
+  },
+  "locals": {
+    "name": "b"
+  },
+  "resource": {
+    "aws_s3_bucket": {
+      "logs": {}
+    }
+  }
+}
+`,
+				"s3.tf.json": `{
+  "resource": {
+    "aws_s3_bucket": {
+      "logs": {}
+    }
+  },
+  "terraform": {
+    "required_providers": {
+      "aws": {"source": "hashicorp/aws"}
+    }
+  }
+}
+`,
+				"versions.tf": `terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+`,
+			},
+			want: []string{
+				"variable.x is defined more than once: main.tf:1, main.tf.json:3",
+				"provider.aws.east is defined more than once: main.tf:3, main.tf.json:6",
+				"local.name is defined more than once: main.tf:8, main.tf.json:12",
+				"resource.aws_s3_bucket.logs is defined more than once: main.tf.json:16, s3.tf.json:4",
+				"required_providers.aws is defined more than once: s3.tf.json:9, versions.tf:3",
+			},
+		},
+		{
+			name: "override files are skipped",
+			files: map[string]string{
+				"main.tf":          "resource \"aws_s3_bucket\" \"logs\" {}\n",
+				"main_override.tf": "resource \"aws_s3_bucket\" \"logs\" {\n  force_destroy = true\n}\n",
+				"override.tofu":    "resource \"aws_s3_bucket\" \"logs\" {}\n",
+				"override.tf.json": "{\"resource\": {\"aws_s3_bucket\": {\"logs\": {}}}}\n",
+			},
+		},
+		{
+			name: "definitions on the same line are ordered by address",
+			files: map[string]string{
+				"a.tf.json": "{\"locals\": {\"b\": 1, \"c\": 2, \"a\": 3}}\n",
+				"b.tf.json": "{\"locals\": {\"c\": 4, \"a\": 5, \"b\": 6}}\n",
+			},
+			want: []string{
+				"local.a is defined more than once: a.tf.json:1, b.tf.json:1",
+				"local.b is defined more than once: a.tf.json:1, b.tf.json:1",
+				"local.c is defined more than once: a.tf.json:1, b.tf.json:1",
+			},
+		},
+		{
+			name: "files that do not parse are skipped",
+			files: map[string]string{
+				"broken.tf": "variable \"region\" {\n",
+				"main.tf":   "variable \"region\" {}\n",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := make(map[string][]byte)
+			for name, content := range tc.files {
+				files[name] = []byte(content)
+			}
+			var got []string
+			for _, duplicate := range FindDuplicates(files) {
+				got = append(got, duplicate.String())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("FindDuplicates() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package module
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Tree is a module directory together with the local modules it calls.
+type Tree struct {
+	Dir string
+	// Children lists the called local modules in the order of their module
+	// blocks, sorted by file name. A module called twice is listed once.
+	Children []*Tree
+	// Repeated marks a module that is listed earlier in the tree; its children
+	// are not listed again.
+	Repeated bool
+	// Err is set for a called module whose directory cannot be read, such as
+	// one that does not exist. The module is left out of Dirs.
+	Err error
+}
+
+// LoadTree builds the tree of the local modules called, directly or
+// transitively, by the root module in dir. Module sources that are local paths
+// (starting with ./ or ../) are followed; registry and remote sources are not.
+// A module that calls itself through its children is an error, and so is a root
+// module that cannot be read; a called module that cannot be read has its Err set.
+func LoadTree(dir string) (*Tree, error) {
+	return loadTree(filepath.Clean(dir), nil, make(map[string]bool))
+}
+
+// loadTree builds the tree of the module in dir. stack holds the directories
+// of the modules calling it, and seen the directories already in the tree.
+func loadTree(dir string, stack []string, seen map[string]bool) (*Tree, error) {
+	tree := &Tree{Dir: dir}
+	for i, caller := range stack {
+		if caller == dir {
+			cycle := append(append([]string{}, stack[i:]...), dir)
+			return nil, fmt.Errorf("module cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if seen[dir] {
+		tree.Repeated = true
+		return tree, nil
+	}
+	seen[dir] = true
+
+	sources, err := localSources(dir)
+	if err != nil {
+		if len(stack) == 0 {
+			return nil, err
+		}
+		tree.Err = fmt.Errorf("skipping module %s called by %s: %w", dir, stack[len(stack)-1], err)
+		return tree, nil
+	}
+	stack = append(stack, dir)
+	for _, source := range sources {
+		child, err := loadTree(filepath.Join(dir, source), stack, seen)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, child)
+	}
+	return tree, nil
+}
+
+// localSources returns the local sources of the module blocks in the
+// Terraform and OpenTofu files of dir, each once. Files that do not parse are
+// skipped.
+func localSources(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module directory: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".tf" || ext == ".tofu") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var sources []string
+	listed := make(map[string]bool)
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(dir, name), err)
+		}
+		file, diags := hclsyntax.ParseConfig(content, name, hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "module" {
+				continue
+			}
+			source := stringAttribute(block.Body, "source")
+			if !isLocalSource(source) {
+				continue
+			}
+			source = filepath.Clean(filepath.FromSlash(source))
+			if !listed[source] {
+				listed[source] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources, nil
+}
+
+// isLocalSource reports whether a module source is a local path. Terraform
+// only treats sources starting with ./ or ../ as local.
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// Dirs returns the directories of the tree, each once, in tree order.
+func (t *Tree) Dirs() []string {
+	if t.Repeated || t.Err != nil {
+		return nil
+	}
+	dirs := []string{t.Dir}
+	for _, child := range t.Children {
+		dirs = append(dirs, child.Dirs()...)
+	}
+	return dirs
+}
+
+// Errors returns the errors of the modules of the tree that cannot be read, in
+// tree order.
+func (t *Tree) Errors() []error {
+	if t.Err != nil {
+		return []error{t.Err}
+	}
+	var errs []error
+	for _, child := range t.Children {
+		errs = append(errs, child.Errors()...)
+	}
+	return errs
+}
+
+// String renders the tree with one module per line, indenting called modules
+// below their caller.
+func (t *Tree) String() string {
+	var b strings.Builder
+	t.write(&b, "", "")
+	return b.String()
+}
+
+// write renders the tree into b. prefix starts the line of the module, and
+// childPrefix the lines of its children.
+func (t *Tree) write(b *strings.Builder, prefix, childPrefix string) {
+	b.WriteString(prefix + t.Dir)
+	switch {
+	case t.Repeated:
+		b.WriteString(" (see above)")
+	case t.Err != nil:
+		b.WriteString(" (skipped)")
+	}
+	b.WriteString("\n")
+	for i, child := range t.Children {
+		if i == len(t.Children)-1 {
+			child.write(b, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.write(b, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
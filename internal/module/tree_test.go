@@ -0,0 +1,146 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+}
+
+func TestLoadTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"live/main.tf": `module "app" {
+  source = "../modules/app"
+}
+
+module "db" {
+  source = "../modules/db"
+}
+
+module "remote" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+`,
+		"live/extra.tf":           "module \"app_again\" {\n  source = \"../modules/app/\"\n}\n",
+		"modules/app/main.tf":     "module \"labels\" {\n  source = \"../labels\"\n}\n",
+		"modules/db/main.tf":      "module \"labels\" {\n  source = \"../labels\"\n}\n",
+		"modules/labels/main.tf":  "output \"tags\" {\n  value = {}\n}\n",
+		"modules/unused/main.tf":  "output \"x\" {\n  value = 1\n}\n",
+		"live/.terraform/main.tf": "module \"x\" {\n  source = \"../../modules/unused\"\n}\n",
+	})
+
+	tree, err := LoadTree(filepath.Join(dir, "live"))
+	if err != nil {
+		t.Fatalf("LoadTree() unexpected error = %v", err)
+	}
+
+	rel := func(path string) string {
+		r, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatalf("filepath.Rel() unexpected error = %v", err)
+		}
+		return filepath.ToSlash(r)
+	}
+	var gotDirs []string
+	for _, d := range tree.Dirs() {
+		gotDirs = append(gotDirs, rel(d))
+	}
+	wantDirs := []string{"live", "modules/app", "modules/labels", "modules/db"}
+	if !reflect.DeepEqual(gotDirs, wantDirs) {
+		t.Errorf("Dirs() = %v, want %v", gotDirs, wantDirs)
+	}
+
+	root := filepath.Join(dir, "live")
+	app := filepath.Join(dir, "modules", "app")
+	labels := filepath.Join(dir, "modules", "labels")
+	db := filepath.Join(dir, "modules", "db")
+	wantString := root + "\n" +
+		"├── " + app + "\n" +
+		"│   └── " + labels + "\n" +
+		"└── " + db + "\n" +
+		"    └── " + labels + " (see above)\n"
+	if got := tree.String(); got != wantString {
+		t.Errorf("String() =\n%s\nwant:\n%s", got, wantString)
+	}
+}
+
+func TestLoadTreeErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a/main.tf": "module \"b\" {\n  source = \"../b\"\n}\n",
+				"b/main.tf": "module \"c\" {\n  source = \"../c\"\n}\n",
+				"c/main.tf": "module \"a\" {\n  source = \"../a\"\n}\n",
+			},
+			wantErr: "module cycle: ",
+		},
+		{
+			name: "missing root module directory",
+			files: map[string]string{
+				"b/main.tf": "module \"c\" {\n  source = \"../c\"\n}\n",
+			},
+			wantErr: "failed to read module directory",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			_, err := LoadTree(filepath.Join(dir, "a"))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("LoadTree() error = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTreeMissingModule(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a/main.tf": "module \"missing\" {\n  source = \"./missing\"\n}\n\nmodule \"b\" {\n  source = \"../b\"\n}\n",
+		"b/main.tf": "output \"x\" {\n  value = 1\n}\n",
+	})
+
+	root := filepath.Join(dir, "a")
+	tree, err := LoadTree(root)
+	if err != nil {
+		t.Fatalf("LoadTree() unexpected error = %v", err)
+	}
+	missing := filepath.Join(root, "missing")
+	b := filepath.Join(dir, "b")
+	if got, want := tree.Dirs(), []string{root, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dirs() = %v, want %v", got, want)
+	}
+	errs := tree.Errors()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "skipping module "+missing) {
+		t.Errorf("Errors() = %v, want an error skipping %s", errs, missing)
+	}
+	wantString := root + "\n" +
+		"├── " + missing + " (skipped)\n" +
+		"└── " + b + "\n"
+	if got := tree.String(); got != wantString {
+		t.Errorf("String() =\n%s\nwant:\n%s", got, wantString)
+	}
+}
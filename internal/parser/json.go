@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JSONFile is a document in Terraform JSON syntax, such as a .tf.json or
+// .tfvars.json file. Unlike encoding/json maps, it keeps object members in
+// their source order so that they can be reordered explicitly.
+type JSONFile struct {
+	Root *JSONObject
+}
+
+// JSONObject is a JSON object with its members in order.
+type JSONObject struct {
+	Members []JSONMember
+}
+
+// JSONMember is a single member of a JSON object. Value is one of *JSONObject,
+// []any, json.Number, string, bool or nil.
+type JSONMember struct {
+	Key   string
+	Value any
+}
+
+// Get returns the value of the first member named key.
+func (o *JSONObject) Get(key string) (any, bool) {
+	for _, member := range o.Members {
+		if member.Key == key {
+			return member.Value, true
+		}
+	}
+	return nil, false
+}
+
+// ParseJSON parses content in JSON syntax. The root value must be an object.
+// filename is used for context in error messages.
+func ParseJSON(content []byte, filename string) (*JSONFile, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+
+	root, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON at offset %d: %w", filename, dec.InputOffset(), err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("%s: invalid JSON at offset %d: unexpected data after the root object", filename, dec.InputOffset())
+	}
+
+	object, ok := root.(*JSONObject)
+	if !ok {
+		return nil, fmt.Errorf("%s: the root of a JSON configuration must be an object", filename)
+	}
+	return &JSONFile{Root: object}, nil
+}
+
+// decodeJSONValue reads the next value from dec.
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		object := &JSONObject{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, errors.New("object key is not a string")
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			object.Members = append(object.Members, JSONMember{Key: key, Value: value})
+		}
+		if _, err := dec.Token(); err != nil { // Closing brace
+			return nil, err
+		}
+		return object, nil
+	case json.Delim('['):
+		array := []any{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		if _, err := dec.Token(); err != nil { // Closing bracket
+			return nil, err
+		}
+		return array, nil
+	}
+	return tok, nil
+}
+
+// Bytes returns the document as JSON indented with two spaces and ending with
+// a newline.
+func (f *JSONFile) Bytes() []byte {
+	var buf bytes.Buffer
+	writeJSONValue(&buf, f.Root, 0)
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// writeJSONValue writes value to buf at the given nesting depth.
+func writeJSONValue(buf *bytes.Buffer, value any, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch v := value.(type) {
+	case *JSONObject:
+		if len(v.Members) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{\n")
+		for i, member := range v.Members {
+			buf.WriteString(indent + "  ")
+			writeJSONString(buf, member.Key)
+			buf.WriteString(": ")
+			writeJSONValue(buf, member.Value, depth+1)
+			if i < len(v.Members)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for i, element := range v {
+			buf.WriteString(indent + "  ")
+			writeJSONValue(buf, element, depth+1)
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	case string:
+		writeJSONString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// writeJSONString writes s as a JSON string without escaping HTML characters,
+// which are common in Terraform expressions such as "${a > b}".
+func writeJSONString(buf *bytes.Buffer, s string) {
+	var encoded bytes.Buffer
+	enc := json.NewEncoder(&encoded)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // Encoding a string cannot fail
+	buf.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
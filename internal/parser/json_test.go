@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "keeps member order",
+			content: `{"resource": {"b": {}, "a": {"count": 1, "tags": ["x", true, null, 1.50]}}, "//": "note"}`,
+			want: `{
+  "resource": {
+    "b": {},
+    "a": {
+      "count": 1,
+      "tags": [
+        "x",
+        true,
+        null,
+        1.50
+      ]
+    }
+  },
+  "//": "note"
+}
+`,
+		},
+		{
+			name:    "does not escape HTML characters",
+			content: `{"locals": {"check": "${var.a > 1 && var.b < 2}"}, "empty": []}`,
+			want: `{
+  "locals": {
+    "check": "${var.a > 1 && var.b < 2}"
+  },
+  "empty": []
+}
+`,
+		},
+		{
+			name:    "invalid syntax",
+			content: `{"a": }`,
+			wantErr: "invalid JSON",
+		},
+		{
+			name:    "trailing data",
+			content: `{} {}`,
+			wantErr: "unexpected data after the root object",
+		},
+		{
+			name:    "root is not an object",
+			content: `["a"]`,
+			wantErr: "must be an object",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := ParseJSON([]byte(tc.content), "test.tf.json")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ParseJSON() error = %v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJSON() unexpected error = %v", err)
+			}
+			if got := string(file.Bytes()); got != tc.want {
+				t.Errorf("Bytes() mismatch\nGot:\n%s\nWant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
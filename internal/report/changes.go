@@ -0,0 +1,334 @@
+package report
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// Rule IDs of the findings.
+const (
+	RuleBlockOrder      = "block-order"
+	RuleTypeNameOrder   = "type-name-order"
+	RuleListOrder       = "list-order"
+	RuleFileOrder       = "file-order"
+	RuleParseError      = "parse-error"
+	RuleProcessingError = "processing-error"
+)
+
+// finding is a change together with the rule it falls under and the source it
+// affects in the original file.
+type finding struct {
+	Change
+	Rule string
+	// Range is the source of the change in the original file, or nil if it
+	// could not be located.
+	Range *hcl.Range
+	// Replacement is the sorted text of Range, if the change is limited to it.
+	Replacement []byte
+	// GluedTo names the block that Directive glues a moved block to.
+	GluedTo   string
+	Directive string
+}
+
+// Changes returns the changes that turned original into sorted: the moved
+// blocks and sorted lists that the sorter recorded in explanation, located in
+// the two versions, and a sorted file if the file changed in other ways or
+// the changes cannot be located, as in a file in JSON syntax.
+func Changes(original, sorted []byte, filename string, explanation *sorter.Explanation) []Change {
+	var changes []Change
+	for _, f := range findChanges(original, sorted, filename, explanation) {
+		changes = append(changes, f.Change)
+	}
+	return changes
+}
+
+// findChanges returns the findings for the changes recorded in explanation
+// that turned original into sorted, followed by a file-order finding for the
+// changes they do not describe.
+func findChanges(original, sorted []byte, filename string, explanation *sorter.Explanation) []finding {
+	if bytes.Equal(original, sorted) {
+		return nil
+	}
+	var findings []finding
+	oldBody, oldOK := parseBody(original, filename)
+	newBody, newOK := parseBody(sorted, filename)
+	if explanation != nil && oldOK && newOK {
+		findings = blockMoves(oldBody, explanation.Blocks)
+		findings = append(findings, sortedLists(oldBody, original, newBody, sorted, explanation.Lists)...)
+	}
+	if len(findings) == 0 || !oldOK || !newOK || hasUncoveredChanges(oldBody, original, newBody, sorted, findings) {
+		findings = append(findings, finding{Change: Change{Kind: ChangeFileSorted}, Rule: RuleFileOrder})
+	}
+	return findings
+}
+
+// hasUncoveredChanges reports whether sorting original into sorted, with the
+// bodies oldBody and newBody, changed more than changes describe: the order or
+// text of the top-level attributes, or the text of a block apart from its
+// sorted lists, such as the order of its attributes. Whitespace is not
+// compared, and neither are the comments between top-level blocks, which move
+// with the blocks.
+func hasUncoveredChanges(oldBody *hclsyntax.Body, original []byte, newBody *hclsyntax.Body, sorted []byte, changes []finding) bool {
+	var lists []finding
+	for _, f := range changes {
+		if f.Kind != ChangeListSorted {
+			continue
+		}
+		if f.Replacement == nil {
+			return true
+		}
+		lists = append(lists, f)
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Range.Start.Byte < lists[j].Range.Start.Byte
+	})
+	// oldText returns the text of rng in original with the sorted lists in
+	// it replaced by their sorted text, and newText the text of rng in sorted.
+	oldText := func(rng hcl.Range) string {
+		var b strings.Builder
+		at := rng.Start.Byte
+		for _, f := range lists {
+			if f.Range.Start.Byte < at || f.Range.End.Byte > rng.End.Byte {
+				continue
+			}
+			b.Write(original[at:f.Range.Start.Byte])
+			b.Write(f.Replacement)
+			at = f.Range.End.Byte
+		}
+		b.Write(original[at:rng.End.Byte])
+		return elementKey(b.String())
+	}
+	newText := func(rng hcl.Range) string {
+		return elementKey(string(rng.SliceBytes(sorted)))
+	}
+
+	oldAttrs, newAttrs := sortedAttributes(oldBody), sortedAttributes(newBody)
+	if len(oldAttrs) != len(newAttrs) || len(oldBody.Blocks) != len(newBody.Blocks) {
+		return true
+	}
+	for i := range oldAttrs {
+		if oldAttrs[i].Name != newAttrs[i].Name || oldText(oldAttrs[i].SrcRange) != newText(newAttrs[i].SrcRange) {
+			return true
+		}
+	}
+
+	// Moved blocks are paired by their indexes, and the blocks that stayed
+	// fill the other places in their original order.
+	moved := make(map[int]int)
+	taken := make(map[int]bool)
+	for _, f := range changes {
+		if f.Kind != ChangeBlockMoved {
+			continue
+		}
+		if f.OldIndex == nil {
+			return true
+		}
+		moved[*f.OldIndex] = *f.NewIndex
+		taken[*f.NewIndex] = true
+	}
+	next := 0
+	for i, block := range oldBody.Blocks {
+		j, ok := moved[i]
+		if !ok {
+			for taken[next] {
+				next++
+			}
+			j = next
+			next++
+		}
+		if j >= len(newBody.Blocks) || oldText(block.Range()) != newText(newBody.Blocks[j].Range()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBody parses content and returns its body.
+func parseBody(content []byte, filename string) (*hclsyntax.Body, bool) {
+	file, diags := hclsyntax.ParseConfig(content, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	return body, ok
+}
+
+// blockMoves returns a finding for every moved block. Blocks that changed
+// places with blocks of their own type fall under the type-name-order rule,
+// and the others under the block-order rule. A block glued to another by a
+// directive falls under the rule of that block, or the block-order rule if
+// that block stayed.
+func blockMoves(oldBody *hclsyntax.Body, moves []sorter.BlockMove) []finding {
+	rules := make(map[string]string)
+	for _, move := range moves {
+		if move.GluedTo == "" {
+			rules[move.Name] = moveRule(move)
+		}
+	}
+
+	var findings []finding
+	for _, move := range moves {
+		newIndex := move.NewIndex
+		f := finding{
+			Change: Change{
+				Kind:     ChangeBlockMoved,
+				Address:  move.Address,
+				Name:     move.Name,
+				NewIndex: &newIndex,
+				Before:   move.Before,
+				After:    move.After,
+			},
+			Rule:      moveRule(move),
+			GluedTo:   move.GluedTo,
+			Directive: move.Directive,
+		}
+		// A glued block moves because its leader does, or to join it.
+		if move.GluedTo != "" {
+			f.Rule = RuleBlockOrder
+			if rule, ok := rules[move.GluedTo]; ok {
+				f.Rule = rule
+			}
+		}
+		if oldIndex, ok := blockIndex(oldBody, move.Address, move.Occurrence); ok {
+			rng := oldBody.Blocks[oldIndex].Range()
+			f.OldIndex = &oldIndex
+			f.Range = &rng
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// moveRule returns the rule a moved block falls under.
+func moveRule(move sorter.BlockMove) string {
+	if move.WithinType {
+		return RuleTypeNameOrder
+	}
+	return RuleBlockOrder
+}
+
+// blockIndex returns the index of the top-level block of body with the given
+// address that has occurrence blocks with the same address before it.
+func blockIndex(body *hclsyntax.Body, address string, occurrence int) (int, bool) {
+	for i, block := range body.Blocks {
+		if blockAddress(block) != address {
+			continue
+		}
+		if occurrence == 0 {
+			return i, true
+		}
+		occurrence--
+	}
+	return 0, false
+}
+
+// listValue is a list expression found in an attribute.
+type listValue struct {
+	Elements []string
+	Range    hcl.Range
+}
+
+// sortedLists returns a finding for every list the sorter sorted. A list is
+// located by the path of its attribute and its elements before and after
+// sorting.
+func sortedLists(oldBody *hclsyntax.Body, original []byte, newBody *hclsyntax.Body, sorted []byte, lists []sorter.ListChange) []finding {
+	oldLists := make(map[string][]listValue)
+	collectLists(oldBody, original, "", oldLists)
+	newLists := make(map[string][]listValue)
+	collectLists(newBody, sorted, "", newLists)
+
+	var findings []finding
+	for _, list := range lists {
+		if list.Skipped != "" {
+			continue
+		}
+		f := finding{
+			Change: Change{Kind: ChangeListSorted, Path: list.Path},
+			Rule:   RuleListOrder,
+		}
+		if old, ok := takeList(oldLists, list.Path, list.Before); ok {
+			f.StartLine = old.Range.Start.Line
+			f.EndLine = old.Range.End.Line
+			f.Range = &old.Range
+			if sortedList, ok := takeList(newLists, list.Path, list.After); ok {
+				f.Replacement = sortedList.Range.SliceBytes(sorted)
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// takeList removes and returns the first list of lists at path with the given
+// elements.
+func takeList(lists map[string][]listValue, path string, elements []string) (listValue, bool) {
+	keys := make([]string, len(elements))
+	for i, element := range elements {
+		keys[i] = elementKey(element)
+	}
+	for i, list := range lists[path] {
+		if slices.Equal(list.Elements, keys) {
+			lists[path] = slices.Delete(lists[path], i, i+1)
+			return list, true
+		}
+	}
+	return listValue{}, false
+}
+
+// collectLists records the list expressions of the attributes in body, and in
+// its nested blocks, by attribute path. Paths start with the address of the
+// top-level block, followed by the types of the nested blocks, as the sorter
+// records them.
+func collectLists(body *hclsyntax.Body, src []byte, prefix string, lists map[string][]listValue) {
+	for _, attr := range sortedAttributes(body) {
+		path := prefix + attr.Name
+		_ = hclsyntax.VisitAll(attr.Expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			tuple, ok := node.(*hclsyntax.TupleConsExpr)
+			if !ok {
+				return nil
+			}
+			list := listValue{Range: tuple.SrcRange}
+			for _, element := range tuple.Exprs {
+				list.Elements = append(list.Elements, elementKey(string(element.Range().SliceBytes(src))))
+			}
+			lists[path] = append(lists[path], list)
+			return nil
+		})
+	}
+	for _, block := range body.Blocks {
+		blockPrefix := prefix + block.Type + "."
+		if prefix == "" {
+			blockPrefix = blockAddress(block) + "."
+		}
+		collectLists(block.Body, src, blockPrefix, lists)
+	}
+}
+
+// sortedAttributes returns the attributes of body in source order.
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	var attrs []*hclsyntax.Attribute
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	return attrs
+}
+
+// elementKey returns the text of a list element without whitespace, so that
+// reformatted elements compare equal.
+func elementKey(text string) string {
+	return strings.Join(strings.Fields(text), "")
+}
+
+// blockAddress returns the block type followed by its labels, joined by dots.
+func blockAddress(block *hclsyntax.Block) string {
+	return strings.Join(append([]string{block.Type}, block.Labels...), ".")
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+func index(i int) *int {
+	return &i
+}
+
+func TestChanges(t *testing.T) {
+	testCases := []struct {
+		name        string
+		filename    string
+		original    string
+		sorted      string
+		explanation *sorter.Explanation
+		want        []Change
+	}{
+		{
+			name:        "unchanged",
+			original:    "variable \"a\" {}\n",
+			sorted:      "variable \"a\" {}\n",
+			explanation: &sorter.Explanation{},
+		},
+		{
+			name: "moved block",
+			original: `resource "aws_s3_bucket" "logs" {}
+
+variable "region" {}
+
+variable "name" {}
+`,
+			sorted: `variable "region" {}
+
+variable "name" {}
+
+resource "aws_s3_bucket" "logs" {}
+`,
+			explanation: &sorter.Explanation{Blocks: []sorter.BlockMove{
+				{Address: "resource.aws_s3_bucket.logs", Name: "resource.aws_s3_bucket.logs", After: "variable.name", NewIndex: 2},
+			}},
+			want: []Change{
+				{Kind: ChangeBlockMoved, Address: "resource.aws_s3_bucket.logs", Name: "resource.aws_s3_bucket.logs", OldIndex: index(0), NewIndex: index(2), After: "variable.name"},
+			},
+		},
+		{
+			name: "moved block that keeps its index",
+			original: `resource "aws_s3_bucket" "b" {}
+
+resource "aws_iam_role" "a" {}
+
+variable "x" {}
+`,
+			sorted: `variable "x" {}
+
+resource "aws_iam_role" "a" {}
+
+resource "aws_s3_bucket" "b" {}
+`,
+			explanation: &sorter.Explanation{Blocks: []sorter.BlockMove{
+				{Address: "variable.x", Name: "variable.x", Before: "resource.aws_iam_role.a", NewIndex: 0},
+				{Address: "resource.aws_iam_role.a", Name: "resource.aws_iam_role.a", Before: "resource.aws_s3_bucket.b", NewIndex: 1, WithinType: true},
+			}},
+			want: []Change{
+				{Kind: ChangeBlockMoved, Address: "variable.x", Name: "variable.x", OldIndex: index(2), NewIndex: index(0), Before: "resource.aws_iam_role.a"},
+				{Kind: ChangeBlockMoved, Address: "resource.aws_iam_role.a", Name: "resource.aws_iam_role.a", OldIndex: index(1), NewIndex: index(1), Before: "resource.aws_s3_bucket.b"},
+			},
+		},
+		{
+			name: "blocks with the same address",
+			original: `locals {
+  a = 1
+}
+
+terraform {}
+
+locals {
+  b = 2
+}
+`,
+			sorted: `terraform {}
+
+locals {
+  a = 1
+}
+
+locals {
+  b = 2
+}
+`,
+			explanation: &sorter.Explanation{Blocks: []sorter.BlockMove{
+				{Address: "terraform", Name: "terraform", Before: "locals", NewIndex: 0},
+			}},
+			want: []Change{
+				{Kind: ChangeBlockMoved, Address: "terraform", Name: "terraform", OldIndex: index(1), NewIndex: index(0), Before: "locals"},
+			},
+		},
+		{
+			name: "moved blocks with the same address",
+			original: `resource "x" "x" {}
+
+variable "a" {}
+
+output "o" {}
+
+variable "a" {}
+`,
+			sorted: `variable "a" {}
+
+variable "a" {}
+
+resource "x" "x" {}
+
+output "o" {}
+`,
+			explanation: &sorter.Explanation{Blocks: []sorter.BlockMove{
+				{Address: "variable.a", Name: "variable.a#1", Before: "variable.a#2", NewIndex: 0},
+				{Address: "variable.a", Name: "variable.a#2", Before: "resource.x.x", Occurrence: 1, NewIndex: 1},
+			}},
+			want: []Change{
+				{Kind: ChangeBlockMoved, Address: "variable.a", Name: "variable.a#1", OldIndex: index(1), NewIndex: index(0), Before: "variable.a#2"},
+				{Kind: ChangeBlockMoved, Address: "variable.a", Name: "variable.a#2", OldIndex: index(3), NewIndex: index(1), Before: "resource.x.x"},
+			},
+		},
+		{
+			name: "sorted lists",
+			original: `resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = [
+      "10.0.1.0/24",
+      "10.0.0.0/24",
+    ]
+  }
+  tags = ["a", "b"]
+}
+
+zones = ["b", "a"]
+`,
+			sorted: `zones = ["a", "b"]
+
+resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = [
+      "10.0.0.0/24",
+      "10.0.1.0/24",
+    ]
+  }
+  tags = ["a", "b"]
+}
+`,
+			explanation: &sorter.Explanation{Lists: []sorter.ListChange{
+				{Path: "zones", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}},
+				{Path: "resource.aws_security_group.web.ingress.cidr_blocks", Before: []string{`"10.0.1.0/24"`, `"10.0.0.0/24"`}, After: []string{`"10.0.0.0/24"`, `"10.0.1.0/24"`}},
+				{Path: "resource.aws_security_group.web.tags", Before: []string{`"a"`}, Skipped: sorter.SkipTooFewElements},
+			}},
+			want: []Change{
+				{Kind: ChangeListSorted, Path: "zones", StartLine: 11, EndLine: 11},
+				{Kind: ChangeListSorted, Path: "resource.aws_security_group.web.ingress.cidr_blocks", StartLine: 3, EndLine: 6},
+			},
+		},
+		{
+			name:        "unparsable file",
+			original:    "variable \"a\" {\n",
+			sorted:      "variable \"a\" {}\n",
+			explanation: &sorter.Explanation{Blocks: []sorter.BlockMove{{Address: "variable.a", Name: "variable.a"}}},
+			want:        []Change{{Kind: ChangeFileSorted}},
+		},
+		{
+			name:     "no explanation",
+			original: "resource \"b\" \"b\" {}\nvariable \"a\" {}\n",
+			sorted:   "variable \"a\" {}\n\nresource \"b\" \"b\" {}\n",
+			want:     []Change{{Kind: ChangeFileSorted}},
+		},
+		{
+			name:     "JSON syntax",
+			filename: "main.tf.json",
+			original: "{\"variable\": {\"b\": {}, \"a\": {}}}\n",
+			sorted:   "{\"variable\": {\"a\": {}, \"b\": {}}}\n",
+			want:     []Change{{Kind: ChangeFileSorted}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := tc.filename
+			if filename == "" {
+				filename = "main.tf"
+			}
+			got := Changes([]byte(tc.original), []byte(tc.sorted), filename, tc.explanation)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Changes() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// Finding levels.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Finding is a problem found in a file, for reports that show problems next
+// to the lines they concern: an unsorted region of a changed file, or a parse
+// diagnostic or other error of a file that could not be sorted.
+type Finding struct {
+	Rule    string
+	Level   string
+	Message string
+	// Subject names what the finding is about, such as the address of a moved
+	// block or the path of a sorted list. It is empty for parse diagnostics and
+	// for findings about the whole file.
+	Subject string
+	// Range is the span of the finding in the original file, or nil if the
+	// finding concerns the whole file.
+	Range *Range
+
+	fix *findingFix
+}
+
+// findingFix replaces the bytes of the original file between Offset and
+// Offset+Length with Text.
+type findingFix struct {
+	Description string
+	Offset      int
+	Length      int
+	Text        []byte
+}
+
+// Findings returns the findings for a processed file. original and sorted are
+// the contents of the file before and after sorting, and explanation is the
+// record the sorter made of the changes, or nil if the file was not sorted. A
+// changed file has a finding for every block the sorter moved and every list
+// it sorted, and a file-order finding if sorting changed anything else, such
+// as the order of attributes. A file that could not be parsed has a finding
+// for every parse diagnostic, and one that could not be sorted or written for
+// another reason has a single processing-error finding. Other files have none.
+func Findings(file File, original, sorted []byte, explanation *sorter.Explanation) []Finding {
+	var findings []Finding
+	switch file.Status {
+	case StatusError:
+		for _, diag := range file.Diagnostics {
+			message := diag.Summary
+			if diag.Detail != "" {
+				message += ": " + diag.Detail
+			}
+			findings = append(findings, Finding{
+				Rule:    RuleParseError,
+				Level:   diag.Severity,
+				Message: message,
+				Range:   diag.Range,
+			})
+		}
+		if len(file.Diagnostics) == 0 {
+			findings = append(findings, Finding{
+				Rule:    RuleProcessingError,
+				Level:   LevelError,
+				Message: file.Error,
+			})
+		}
+	case StatusChanged:
+		sortFile := &findingFix{Description: "Sort the file", Length: len(original), Text: sorted}
+		for _, f := range findChanges(original, sorted, file.Path, explanation) {
+			finding := Finding{
+				Rule:    f.Rule,
+				Level:   LevelWarning,
+				Message: findingMessage(f),
+				Subject: f.Name,
+				fix:     sortFile,
+			}
+			if f.Range != nil {
+				finding.Range = &Range{Start: position(f.Range.Start), End: position(f.Range.End)}
+			}
+			if f.Kind == ChangeListSorted {
+				finding.Subject = f.Path
+			}
+			if f.Replacement != nil {
+				finding.fix = &findingFix{
+					Description: "Sort the list",
+					Offset:      f.Range.Start.Byte,
+					Length:      f.Range.End.Byte - f.Range.Start.Byte,
+					Text:        f.Replacement,
+				}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// findingMessage describes a change.
+func findingMessage(f finding) string {
+	var message string
+	switch {
+	case f.GluedTo != "":
+		message = fmt.Sprintf("%s is out of order because a %s directive glues it to %s", f.Name, f.Directive, f.GluedTo)
+	case f.Rule == RuleBlockOrder:
+		message = fmt.Sprintf("%s is out of block type order", f.Name)
+	case f.Rule == RuleTypeNameOrder:
+		message = fmt.Sprintf("%s is out of order among the %s blocks", f.Name, strings.SplitN(f.Address, ".", 2)[0])
+	case f.Rule == RuleListOrder:
+		return fmt.Sprintf("The list in %s is not sorted.", f.Path)
+	default:
+		return "The file is not sorted."
+	}
+	switch {
+	case f.Before != "":
+		return fmt.Sprintf("%s; it belongs before %s.", message, f.Before)
+	case f.After != "":
+		return fmt.Sprintf("%s; it belongs after %s.", message, f.After)
+	}
+	return message + "."
+}
+
+// position converts an HCL position.
+func position(pos hcl.Pos) Position {
+	return Position{Line: pos.Line, Column: pos.Column, Byte: pos.Byte}
+}
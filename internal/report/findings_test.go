@@ -0,0 +1,97 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+func TestFindings(t *testing.T) {
+	type result struct {
+		Rule    string
+		Message string
+	}
+	testCases := []struct {
+		name        string
+		path        string
+		original    string
+		sorted      string
+		explanation *sorter.Explanation
+		want        []result
+	}{
+		{
+			name:     "sorted list only",
+			path:     "main.tf",
+			original: "variable \"a\" {\n  x = [\"b\", \"a\"]\n}\n",
+			sorted:   "variable \"a\" {\n  x = [\"a\", \"b\"]\n}\n",
+			explanation: &sorter.Explanation{
+				Lists: []sorter.ListChange{{Path: "variable.a.x", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}}},
+			},
+			want: []result{{RuleListOrder, "The list in variable.a.x is not sorted."}},
+		},
+		{
+			name:     "reordered attributes next to a sorted list",
+			path:     "terraform.tfvars",
+			original: "b = 1\na = [\"y\", \"x\"]\n",
+			sorted:   "a = [\"x\", \"y\"]\nb = 1\n",
+			explanation: &sorter.Explanation{
+				Lists: []sorter.ListChange{{Path: "a", Before: []string{`"y"`, `"x"`}, After: []string{`"x"`, `"y"`}}},
+			},
+			want: []result{
+				{RuleListOrder, "The list in a is not sorted."},
+				{RuleFileOrder, "The file is not sorted."},
+			},
+		},
+		{
+			name:     "sorted type constraint next to a moved block",
+			path:     "main.tf",
+			original: "output \"o\" {}\nvariable \"v\" {\n  type = object({\n    b = string\n    a = string\n  })\n}\n",
+			sorted:   "variable \"v\" {\n  type = object({\n    a = string\n    b = string\n  })\n}\n\noutput \"o\" {}\n",
+			explanation: &sorter.Explanation{
+				Blocks: []sorter.BlockMove{{Address: "variable.v", Name: "variable.v", Before: "output.o"}},
+			},
+			want: []result{
+				{RuleBlockOrder, "variable.v is out of block type order; it belongs before output.o."},
+				{RuleFileOrder, "The file is not sorted."},
+			},
+		},
+		{
+			name:     "glued block",
+			path:     "main.tf",
+			original: "output \"o\" {}\nmoved {\n  from = a.b\n  to   = a.c\n}\noutput \"b\" {}\n# tfsort:keep-with-next\noutput \"a\" {}\nmoved {\n  from = a.d\n  to   = a.e\n}\n",
+			sorted:   "output \"a\" {}\nmoved {\n  from = a.d\n  to   = a.e\n}\n\noutput \"o\" {}\nmoved {\n  from = a.b\n  to   = a.c\n}\noutput \"b\" {}\n",
+			explanation: &sorter.Explanation{
+				Blocks: []sorter.BlockMove{
+					{Address: "output.a", Name: "output.a", Before: "output.o", WithinType: true},
+					{Address: "moved", Name: "moved#2", Before: "output.o", GluedTo: "output.a", Directive: "tfsort:keep-with-next", Occurrence: 1, NewIndex: 1, WithinType: true},
+				},
+			},
+			want: []result{
+				{RuleTypeNameOrder, "output.a is out of order among the output blocks; it belongs before output.o."},
+				{RuleTypeNameOrder, "moved#2 is out of order because a tfsort:keep-with-next directive glues it to output.a; it belongs before output.o."},
+			},
+		},
+		{
+			name:        "changes no other finding describes",
+			path:        "main.tf",
+			original:    "a = 1\n",
+			sorted:      "a = 1\n\n",
+			explanation: &sorter.Explanation{},
+			want:        []result{{RuleFileOrder, "The file is not sorted."}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := File{Path: tc.path, Status: StatusChanged}
+			var got []result
+			for _, f := range Findings(file, []byte(tc.original), []byte(tc.sorted), tc.explanation) {
+				got = append(got, result{f.Rule, f.Message})
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Findings() =\n%+v\nwant:\n%+v", got, tc.want)
+			}
+		})
+	}
+}
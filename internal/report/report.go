@@ -0,0 +1,138 @@
+// Package report describes the outcome of a tfsort run as a machine-readable
+// document: the status of every file, its parse diagnostics and the changes
+// that sorting made to it.
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Status is the outcome of processing a file.
+type Status string
+
+// File statuses.
+const (
+	StatusUnchanged Status = "unchanged"
+	StatusChanged   Status = "changed"
+	StatusError     Status = "error"
+	StatusSkipped   Status = "skipped"
+)
+
+// Report is the outcome of a run.
+type Report struct {
+	Files []File `json:"files"`
+	// Summary holds the summary statistics of the run, if requested.
+	Summary *Summary `json:"summary,omitempty"`
+}
+
+// File is the outcome of processing a single file.
+type File struct {
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+	// Error describes why the file could not be sorted.
+	Error string `json:"error,omitempty"`
+	// Reason describes why the file was skipped.
+	Reason      string       `json:"reason,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	Changes     []Change     `json:"changes,omitempty"`
+}
+
+// Diagnostic is a problem reported by the parser.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Range    *Range `json:"range,omitempty"`
+}
+
+// Range is a span of a source file.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Position is a location in a source file. Line and column are 1-based, and
+// the byte offset is 0-based.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// ChangeKind is the kind of a change made by sorting.
+type ChangeKind string
+
+// Change kinds.
+const (
+	ChangeBlockMoved ChangeKind = "block_moved"
+	ChangeListSorted ChangeKind = "list_sorted"
+	ChangeFileSorted ChangeKind = "file_sorted"
+)
+
+// Change is a single change made by sorting. A moved block has an address, a
+// name that tells apart blocks with the same address, its old and new index
+// among the top-level blocks, and the block it now comes before, or after if
+// it is last. A block can keep its index and still move, when blocks on both
+// sides of it change places; its neighbours tell where it moved. A sorted list
+// has the path of its attribute and its lines in the original file. A sorted
+// file stands for the changes the others do not describe, such as reordered
+// attributes or any change to a file in JSON syntax.
+type Change struct {
+	Kind    ChangeKind `json:"kind"`
+	Address string     `json:"address,omitempty"`
+	// Name is the address, followed by #n for the nth of several blocks with
+	// the same address in the original file, such as locals#2.
+	Name     string `json:"name,omitempty"`
+	OldIndex *int   `json:"old_index,omitempty"`
+	NewIndex *int   `json:"new_index,omitempty"`
+	// Before and After name the block a moved block is placed in front of or,
+	// if it becomes the last block, behind.
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+	Path      string `json:"path,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// Add appends a file to the report.
+func (r *Report) Add(file File) {
+	r.Files = append(r.Files, file)
+}
+
+// Write writes the report to w as indented JSON.
+func (r *Report) Write(w io.Writer) error {
+	files := r.Files
+	if files == nil {
+		files = []File{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Report{Files: files, Summary: r.Summary})
+}
+
+// Diagnostics converts HCL diagnostics to report diagnostics.
+func Diagnostics(diags hcl.Diagnostics) []Diagnostic {
+	var result []Diagnostic
+	for _, diag := range diags {
+		d := Diagnostic{
+			Severity: LevelError,
+			Summary:  diag.Summary,
+			Detail:   diag.Detail,
+		}
+		if diag.Severity == hcl.DiagWarning {
+			d.Severity = LevelWarning
+		}
+		if diag.Subject != nil {
+			d.Range = &Range{
+				Start: position(diag.Subject.Start),
+				End:   position(diag.Subject.End),
+			}
+		}
+		result = append(result, d)
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+func TestWrite(t *testing.T) {
+	_, diags := hclwrite.ParseConfig([]byte("variable \"a\" {\n"), "broken.tf", hcl.InitialPos)
+
+	var r Report
+	r.Add(File{Path: "main.tf", Status: StatusChanged, Changes: []Change{
+		{Kind: ChangeBlockMoved, Address: "variable.a", OldIndex: index(1), NewIndex: index(0)},
+	}})
+	r.Add(File{Path: "broken.tf", Status: StatusError, Error: "failed to parse", Diagnostics: Diagnostics(diags)})
+	r.Add(File{Path: "<stdin>", Status: StatusSkipped, Reason: "empty file"})
+
+	var b bytes.Buffer
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+	want := `{
+  "files": [
+    {
+      "path": "main.tf",
+      "status": "changed",
+      "changes": [
+        {
+          "kind": "block_moved",
+          "address": "variable.a",
+          "old_index": 1,
+          "new_index": 0
+        }
+      ]
+    },
+    {
+      "path": "broken.tf",
+      "status": "error",
+      "error": "failed to parse",
+      "diagnostics": [
+        {
+          "severity": "error",
+          "summary": "Unclosed configuration block",
+          "detail": "There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file.",
+          "range": {
+            "start": {
+              "line": 1,
+              "column": 14,
+              "byte": 13
+            },
+            "end": {
+              "line": 1,
+              "column": 15,
+              "byte": 14
+            }
+          }
+        }
+      ]
+    },
+    {
+      "path": "<stdin>",
+      "status": "skipped",
+      "reason": "empty file"
+    }
+  ]
+}
+`
+	if got := b.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var b bytes.Buffer
+	if err := (&Report{}).Write(&b); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+	if got, want := b.String(), "{\n  \"files\": []\n}\n"; got != want {
+		t.Errorf("Write() = %q, want %q", got, want)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	s.Add("modules/vpc", Counts{Files: 1, Changed: 1, BlocksReordered: 2, ListsSorted: 1})
+	s.Add(".", Counts{Files: 1, Unchanged: 1, ListsIgnored: 1})
+	s.Add("modules/vpc", Counts{Files: 1, Errors: 1})
+	s.Add("", Counts{Files: 1, Skipped: 1})
+
+	want := Counts{Files: 4, Changed: 1, Unchanged: 1, Skipped: 1, Errors: 1, BlocksReordered: 2, ListsSorted: 1, ListsIgnored: 1}
+	if s.Counts != want {
+		t.Errorf("Counts = %+v, want %+v", s.Counts, want)
+	}
+	wantDirs := []DirectorySummary{
+		{Path: ".", Counts: Counts{Files: 1, Unchanged: 1, ListsIgnored: 1}},
+		{Path: "modules/vpc", Counts: Counts{Files: 2, Changed: 1, Errors: 1, BlocksReordered: 2, ListsSorted: 1}},
+	}
+	if len(s.Directories) != len(wantDirs) {
+		t.Fatalf("Directories = %+v, want %+v", s.Directories, wantDirs)
+	}
+	for i := range wantDirs {
+		if s.Directories[i] != wantDirs[i] {
+			t.Errorf("Directories[%d] = %+v, want %+v", i, s.Directories[i], wantDirs[i])
+		}
+	}
+
+	var b bytes.Buffer
+	if err := (&Report{Summary: &Summary{Counts: Counts{Files: 1, Unchanged: 1}, ElapsedSeconds: 0.25}}).Write(&b); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+	wantJSON := `{
+  "files": [],
+  "summary": {
+    "files": 1,
+    "changed": 0,
+    "unchanged": 1,
+    "skipped": 0,
+    "errors": 0,
+    "blocks_reordered": 0,
+    "lists_sorted": 0,
+    "lists_ignored": 0,
+    "elapsed_seconds": 0.25
+  }
+}
+`
+	if got := b.String(); got != wantJSON {
+		t.Errorf("Write() =\n%s\nwant:\n%s", got, wantJSON)
+	}
+}
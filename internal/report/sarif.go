@@ -0,0 +1,203 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+// SARIF schema and version written by SARIFLog.
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// sarifRules describes the rules the results of a SARIF log refer to.
+var sarifRules = []sarifRuleDescriptor{
+	newSARIFRule(RuleBlockOrder, "Top-level blocks are ordered by block type.", LevelWarning),
+	newSARIFRule(RuleTypeNameOrder, "Blocks of the same type are ordered by their labels.", LevelWarning),
+	newSARIFRule(RuleListOrder, "The elements of list values are sorted.", LevelWarning),
+	newSARIFRule(RuleFileOrder, "The file is sorted, including the order of attributes, locals and object type attributes.", LevelWarning),
+	newSARIFRule(RuleParseError, "The file can be parsed.", LevelError),
+	newSARIFRule(RuleProcessingError, "The file can be sorted and written.", LevelError),
+}
+
+// SARIFLog collects the findings of a run as a SARIF 2.1.0 log, the format
+// read by code scanning tools.
+type SARIFLog struct {
+	results []sarifResult
+}
+
+// Add adds a result for every finding of a processed file, with a fix for
+// those of a changed file. original and sorted are the contents of the file
+// before and after sorting, and explanation the record of the changes.
+func (l *SARIFLog) Add(file File, original, sorted []byte, explanation *sorter.Explanation) {
+	uri := artifactURI(file.Path)
+	for _, f := range Findings(file, original, sorted, explanation) {
+		region := sarifRegion{StartLine: 1}
+		if f.Range != nil {
+			region = sarifRegion{
+				StartLine:   f.Range.Start.Line,
+				StartColumn: f.Range.Start.Column,
+				EndLine:     f.Range.End.Line,
+				EndColumn:   f.Range.End.Column,
+			}
+		}
+		result := sarifResult{
+			RuleID:  f.Rule,
+			Level:   f.Level,
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Region:           region,
+				},
+			}},
+		}
+		if f.fix != nil {
+			offset := f.fix.Offset
+			result.Fixes = []sarifFix{{
+				Description: sarifMessage{Text: f.fix.Description},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Replacements: []sarifReplacement{{
+						DeletedRegion:   sarifRegion{ByteOffset: &offset, ByteLength: f.fix.Length},
+						InsertedContent: &sarifContent{Text: string(f.fix.Text)},
+					}},
+				}},
+			}}
+		}
+		l.results = append(l.results, result)
+	}
+}
+
+// Write writes the log to w as indented JSON.
+func (l *SARIFLog) Write(w io.Writer) error {
+	results := l.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+	document := sarifDocument{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "tfsort",
+				InformationURI: "https://github.com/tjun/tfsort",
+				Rules:          sarifRules,
+			}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// artifactURI returns path as a relative URI reference, or as a file URI if
+// it is absolute.
+func artifactURI(path string) string {
+	uri := filepath.ToSlash(filepath.Clean(path))
+	if filepath.IsAbs(path) {
+		return "file://" + uri
+	}
+	return uri
+}
+
+func newSARIFRule(id, description, level string) sarifRuleDescriptor {
+	return sarifRuleDescriptor{
+		ID:                   id,
+		ShortDescription:     sarifMessage{Text: description},
+		DefaultConfiguration: sarifConfiguration{Level: level},
+	}
+}
+
+// The types below are the parts of the SARIF 2.1.0 object model that tfsort
+// writes.
+
+type sarifDocument struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                `json:"name"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []sarifRuleDescriptor `json:"rules"`
+}
+
+type sarifRuleDescriptor struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int  `json:"startLine,omitempty"`
+	StartColumn int  `json:"startColumn,omitempty"`
+	EndLine     int  `json:"endLine,omitempty"`
+	EndColumn   int  `json:"endColumn,omitempty"`
+	ByteOffset  *int `json:"byteOffset,omitempty"`
+	ByteLength  int  `json:"byteLength,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifContent `json:"insertedContent,omitempty"`
+}
+
+type sarifContent struct {
+	Text string `json:"text"`
+}
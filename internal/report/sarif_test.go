@@ -0,0 +1,107 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/tjun/tfsort/internal/sorter"
+)
+
+func TestSARIFLog(t *testing.T) {
+	original := `resource "b" "b" {}
+resource "a" "a" {}
+variable "a" {
+  x = ["b", "a"]
+}
+`
+	sorted := `variable "a" {
+  x = ["a", "b"]
+}
+
+resource "a" "a" {}
+
+resource "b" "b" {}
+`
+	_, diags := hclwrite.ParseConfig([]byte("variable \"a\" {\n"), "broken.tf", hcl.InitialPos)
+
+	explanation := &sorter.Explanation{
+		Blocks: []sorter.BlockMove{
+			{Address: "variable.a", Name: "variable.a", Before: "resource.a.a", NewIndex: 0},
+			{Address: "resource.a.a", Name: "resource.a.a", Before: "resource.b.b", NewIndex: 1, WithinType: true},
+		},
+		Lists: []sorter.ListChange{{Path: "variable.a.x", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}}},
+	}
+
+	var l SARIFLog
+	l.Add(File{Path: "./modules/app/main.tf", Status: StatusChanged}, []byte(original), []byte(sorted), explanation)
+	l.Add(File{Path: "modules/app/other.tf", Status: StatusChanged}, []byte("a = 1\n"), []byte("a = 1\n\n"), &sorter.Explanation{})
+	l.Add(File{Path: "modules/app/sorted.tf", Status: StatusUnchanged}, []byte(sorted), []byte(sorted), &sorter.Explanation{})
+	l.Add(File{Path: "broken.tf", Status: StatusError, Diagnostics: Diagnostics(diags)}, []byte("variable \"a\" {\n"), nil, nil)
+
+	var b bytes.Buffer
+	if err := l.Write(&b); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+	var document sarifDocument
+	if err := json.Unmarshal(b.Bytes(), &document); err != nil {
+		t.Fatalf("Write() wrote invalid JSON: %v\n%s", err, b.String())
+	}
+	if document.Version != "2.1.0" || len(document.Runs) != 1 {
+		t.Fatalf("Write() version = %q with %d runs, want 2.1.0 with 1 run", document.Version, len(document.Runs))
+	}
+	run := document.Runs[0]
+	if run.Tool.Driver.Name != "tfsort" || len(run.Tool.Driver.Rules) != len(sarifRules) {
+		t.Errorf("driver = %+v, want tfsort with %d rules", run.Tool.Driver, len(sarifRules))
+	}
+
+	type result struct {
+		Rule    string
+		Level   string
+		Message string
+		URI     string
+		Region  sarifRegion
+	}
+	var got []result
+	for _, r := range run.Results {
+		location := r.Locations[0].PhysicalLocation
+		got = append(got, result{r.RuleID, r.Level, r.Message.Text, location.ArtifactLocation.URI, location.Region})
+	}
+	want := []result{
+		{RuleBlockOrder, "warning", "variable.a is out of block type order; it belongs before resource.a.a.", "modules/app/main.tf", sarifRegion{StartLine: 3, StartColumn: 1, EndLine: 5, EndColumn: 2}},
+		{RuleTypeNameOrder, "warning", "resource.a.a is out of order among the resource blocks; it belongs before resource.b.b.", "modules/app/main.tf", sarifRegion{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 20}},
+		{RuleListOrder, "warning", "The list in variable.a.x is not sorted.", "modules/app/main.tf", sarifRegion{StartLine: 4, StartColumn: 7, EndLine: 4, EndColumn: 17}},
+		{RuleFileOrder, "warning", "The file is not sorted.", "modules/app/other.tf", sarifRegion{StartLine: 1}},
+		{RuleParseError, "error", "Unclosed configuration block: There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file.", "broken.tf", sarifRegion{StartLine: 1, StartColumn: 14, EndLine: 1, EndColumn: 15}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results =\n%+v\nwant:\n%+v", got, want)
+	}
+
+	// The fix of a sorted list replaces the list, and the fix of a moved block
+	// replaces the file.
+	listFix := run.Results[2].Fixes[0].ArtifactChanges[0].Replacements[0]
+	if listFix.DeletedRegion.ByteOffset == nil || *listFix.DeletedRegion.ByteOffset != 61 || listFix.DeletedRegion.ByteLength != 10 || listFix.InsertedContent.Text != `["a", "b"]` {
+		t.Errorf("list fix = %+v, want the list at bytes 61-71 replaced with the sorted list", listFix)
+	}
+	blockFix := run.Results[0].Fixes[0].ArtifactChanges[0].Replacements[0]
+	if blockFix.DeletedRegion.ByteLength != len(original) || blockFix.InsertedContent.Text != sorted {
+		t.Errorf("block fix = %+v, want the file replaced with the sorted file", blockFix)
+	}
+	if fixes := run.Results[4].Fixes; len(fixes) != 0 {
+		t.Errorf("parse error fixes = %+v, want none", fixes)
+	}
+}
+
+func TestSARIFLogEmpty(t *testing.T) {
+	var b bytes.Buffer
+	if err := (&SARIFLog{}).Write(&b); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+	if !bytes.Contains(b.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("Write() = %s, want an empty results array", b.String())
+	}
+}
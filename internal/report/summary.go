@@ -0,0 +1,64 @@
+package report
+
+import "sort"
+
+// Counts counts the outcome of the files of a run.
+type Counts struct {
+	// Files is the number of files and other inputs the run was given or
+	// found: the sum of Changed, Unchanged, Skipped and Errors.
+	Files     int `json:"files"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+	Errors    int `json:"errors"`
+	// BlocksReordered, ListsSorted and ListsIgnored count the top-level blocks
+	// sorting moved, the lists it sorted, and the lists a tfsort:ignore
+	// directive kept it from sorting, in files in HCL native syntax.
+	BlocksReordered int `json:"blocks_reordered"`
+	ListsSorted     int `json:"lists_sorted"`
+	ListsIgnored    int `json:"lists_ignored"`
+}
+
+// add adds the counts of other to c.
+func (c *Counts) add(other Counts) {
+	c.Files += other.Files
+	c.Changed += other.Changed
+	c.Unchanged += other.Unchanged
+	c.Skipped += other.Skipped
+	c.Errors += other.Errors
+	c.BlocksReordered += other.BlocksReordered
+	c.ListsSorted += other.ListsSorted
+	c.ListsIgnored += other.ListsIgnored
+}
+
+// Summary is the summary statistics of a run, with an optional breakdown by
+// directory.
+type Summary struct {
+	Counts
+	ElapsedSeconds float64            `json:"elapsed_seconds"`
+	Directories    []DirectorySummary `json:"directories,omitempty"`
+}
+
+// DirectorySummary counts the files of a run in one directory.
+type DirectorySummary struct {
+	Path string `json:"path"`
+	Counts
+}
+
+// Add adds the counts of a file to the totals and, unless dir is empty, to the
+// directory dir. Directories are kept sorted by path.
+func (s *Summary) Add(dir string, c Counts) {
+	s.Counts.add(c)
+	if dir == "" {
+		return
+	}
+	i := sort.Search(len(s.Directories), func(i int) bool {
+		return s.Directories[i].Path >= dir
+	})
+	if i == len(s.Directories) || s.Directories[i].Path != dir {
+		s.Directories = append(s.Directories, DirectorySummary{})
+		copy(s.Directories[i+1:], s.Directories[i:])
+		s.Directories[i] = DirectorySummary{Path: dir}
+	}
+	s.Directories[i].add(c)
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ProviderSchemas is the subset of the `terraform providers schema -json` output used by tfsort.
+type ProviderSchemas struct {
+	FormatVersion   string               `json:"format_version"`
+	ProviderSchemas map[string]*Provider `json:"provider_schemas"`
+}
+
+// Provider holds the resource and data source schemas of a single provider.
+type Provider struct {
+	ResourceSchemas   map[string]*Schema `json:"resource_schemas"`
+	DataSourceSchemas map[string]*Schema `json:"data_source_schemas"`
+}
+
+// Schema is the schema of a single resource or data source type.
+type Schema struct {
+	Version int    `json:"version"`
+	Block   *Block `json:"block"`
+}
+
+// Block describes the arguments and nested blocks allowed in a block body.
+type Block struct {
+	Attributes map[string]*Attribute   `json:"attributes"`
+	BlockTypes map[string]*NestedBlock `json:"block_types"`
+}
+
+// Attribute describes a single argument of a block.
+type Attribute struct {
+	Required bool `json:"required"`
+	Optional bool `json:"optional"`
+	Computed bool `json:"computed"`
+}
+
+// NestedBlock describes a nested block type.
+type NestedBlock struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       *Block `json:"block"`
+}
+
+// Load reads provider schemas from a JSON file written by `terraform providers schema -json`.
+func Load(path string) (*ProviderSchemas, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open provider schema %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	schemas, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider schema %q: %w", path, err)
+	}
+	return schemas, nil
+}
+
+// Parse decodes provider schemas from JSON.
+func Parse(r io.Reader) (*ProviderSchemas, error) {
+	var schemas ProviderSchemas
+	if err := json.NewDecoder(r).Decode(&schemas); err != nil {
+		return nil, err
+	}
+	if schemas.ProviderSchemas == nil {
+		return nil, fmt.Errorf("no provider_schemas found")
+	}
+	return &schemas, nil
+}
+
+// Lookup returns the schema of the given resource or data source type.
+// blockType is "resource" or "data". Providers are searched in name order,
+// and nil is returned if no provider defines the type.
+func (s *ProviderSchemas) Lookup(blockType, typeName string) *Block {
+	if s == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(s.ProviderSchemas))
+	for name := range s.ProviderSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		provider := s.ProviderSchemas[name]
+		if provider == nil {
+			continue
+		}
+		schemas := provider.ResourceSchemas
+		if blockType == "data" {
+			schemas = provider.DataSourceSchemas
+		}
+		if schema, ok := schemas[typeName]; ok && schema != nil {
+			return schema.Block
+		}
+	}
+	return nil
+}
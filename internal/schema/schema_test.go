@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchemaJSON = `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/aws": {
+      "resource_schemas": {
+        "aws_s3_bucket": {
+          "version": 0,
+          "block": {
+            "attributes": {
+              "bucket": {"type": "string", "optional": true, "computed": true},
+              "id": {"type": "string", "computed": true}
+            }
+          }
+        }
+      },
+      "data_source_schemas": {
+        "aws_ami": {
+          "version": 0,
+          "block": {
+            "attributes": {
+              "owners": {"type": ["list", "string"], "optional": true}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestParseAndLookup(t *testing.T) {
+	schemas, err := Parse(strings.NewReader(testSchemaJSON))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error = %v", err)
+	}
+
+	bucket := schemas.Lookup("resource", "aws_s3_bucket")
+	if bucket == nil {
+		t.Fatal("Lookup(resource, aws_s3_bucket) = nil, want schema")
+	}
+	if attr := bucket.Attributes["bucket"]; attr == nil || !attr.Optional || !attr.Computed {
+		t.Errorf("bucket attribute = %+v, want optional and computed", attr)
+	}
+
+	if ami := schemas.Lookup("data", "aws_ami"); ami == nil {
+		t.Error("Lookup(data, aws_ami) = nil, want schema")
+	}
+	if got := schemas.Lookup("data", "aws_s3_bucket"); got != nil {
+		t.Errorf("Lookup(data, aws_s3_bucket) = %+v, want nil", got)
+	}
+	if got := schemas.Lookup("resource", "google_compute_instance"); got != nil {
+		t.Errorf("Lookup(resource, google_compute_instance) = %+v, want nil", got)
+	}
+
+	var nilSchemas *ProviderSchemas
+	if got := nilSchemas.Lookup("resource", "aws_s3_bucket"); got != nil {
+		t.Errorf("nil Lookup() = %+v, want nil", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("not json")); err == nil {
+		t.Error("Parse(invalid JSON) error = nil, want error")
+	}
+	if _, err := Parse(strings.NewReader(`{"format_version": "1.0"}`)); err == nil {
+		t.Error("Parse(without provider_schemas) error = nil, want error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(testSchemaJSON), 0644); err != nil {
+		t.Fatalf("Failed to write schema file: %v", err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("Load() unexpected error = %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load(missing file) error = nil, want error")
+	}
+}
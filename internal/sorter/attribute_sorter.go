@@ -0,0 +1,232 @@
+package sorter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// newAttributesFile returns a new file holding the top-level attributes of body
+// together with their comments. The attributes keep their original order unless
+// options.SortAttributes is set, in which case they are sorted by name.
+// Attributes the profile places after the blocks are left out of the file and
+// returned as tokens instead, in the order the profile lists them.
+func newAttributesFile(body *hclwrite.Body, options SortOptions) (*hclwrite.File, hclwrite.Tokens, error) {
+	layout := splitFileItems(body)
+	trailingNames := options.profile().TrailingAttributes
+
+	var preamble hclwrite.Tokens
+	if len(layout.Items) > 0 && layout.Items[0].Attribute != nil && !containsString(trailingNames, layout.Items[0].Name) {
+		preamble, layout.Items[0] = splitFilePreamble(layout.Items[0])
+	}
+
+	var attrs []bodyItem
+	trailingItems := make(map[string]bodyItem)
+	hasBlocks := false
+	for _, item := range layout.Items {
+		switch {
+		case item.Attribute == nil:
+			hasBlocks = true
+		case containsString(trailingNames, item.Name):
+			trailingItems[item.Name] = item
+		default:
+			attrs = append(attrs, item)
+		}
+	}
+
+	var trailing []bodyItem
+	for _, name := range trailingNames {
+		if item, ok := trailingItems[name]; ok {
+			trailing = append(trailing, item)
+		}
+	}
+	var trailingTokens hclwrite.Tokens
+	if len(trailing) > 0 {
+		trailingTokens = bodyLayout{Items: withoutBlankLines(trailing)}.BuildTokens()
+	}
+
+	if len(attrs) == 0 {
+		return hclwrite.NewEmptyFile(), trailingTokens, nil
+	}
+
+	if options.SortAttributes {
+		sorted := make([]bodyItem, len(attrs))
+		copy(sorted, attrs)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		})
+		for i := range sorted {
+			if sorted[i].Name != attrs[i].Name {
+				attrs = withoutBlankLines(sorted)
+				break
+			}
+		}
+	}
+
+	attrsLayout := bodyLayout{Preamble: preamble, Items: attrs}
+	if !hasBlocks {
+		// Comments after the last attribute stay at the end of the file.
+		attrsLayout.Trailing = layout.Trailing
+	}
+
+	file, diags := hclwrite.ParseConfig(attrsLayout.BuildTokens().Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("failed to rebuild top-level attributes: %v", diags)
+	}
+	return file, trailingTokens, nil
+}
+
+// appendTrailingAttributes appends the tokens of attributes that follow the
+// blocks to file and re-parses it, so that the attributes can still be reached
+// through the hclwrite API.
+func appendTrailingAttributes(file *hclwrite.File, tokens hclwrite.Tokens) (*hclwrite.File, error) {
+	body := file.Body()
+	if len(body.Attributes()) > 0 || len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	body.AppendUnstructuredTokens(tokens)
+
+	newFile, diags := hclwrite.ParseConfig(file.Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to rebuild trailing attributes: %v", diags)
+	}
+	return newFile, nil
+}
+
+// sortObjectAttributesInBody sorts the keys of the object constructors assigned
+// to the attributes of body whose path matches one of paths, recursing into
+// nested blocks. Attribute paths are built as for list scopes.
+func sortObjectAttributesInBody(body *hclwrite.Body, prefix string, paths []string) {
+	for name, attr := range body.Attributes() {
+		if !matchesScope(paths, prefix+name) {
+			continue
+		}
+		tokens := attr.Expr().BuildTokens(nil)
+		if len(tokens) < 2 || tokens[0].Type != hclsyntax.TokenOBrace || findMatchingClose(tokens, 0) != len(tokens)-1 {
+			continue // Not an object constructor
+		}
+		sortedInner, sorted := sortObjectEntries(tokens[1:len(tokens)-1], nil)
+		if !sorted {
+			continue
+		}
+		newTokens := append(hclwrite.Tokens{tokens[0]}, sortedInner...)
+		newTokens = append(newTokens, tokens[len(tokens)-1])
+		body.SetAttributeRaw(name, newTokens)
+	}
+
+	for _, block := range body.Blocks() {
+		sortObjectAttributesInBody(block.Body(), prefix+block.Type()+".", paths)
+	}
+}
+
+// sortBlockAttributes sorts by name the attributes of the blocks whose path is
+// listed in paths. The path of a block joins the types of the block and its
+// parents with dots, such as "run.variables". Blocks that do not change are
+// returned as-is.
+func sortBlockAttributes(blocks []*hclwrite.Block, paths []string) ([]*hclwrite.Block, error) {
+	if len(paths) == 0 {
+		return blocks, nil
+	}
+
+	result := make([]*hclwrite.Block, len(blocks))
+	for i, block := range blocks {
+		result[i] = block
+		if !hasPathPrefix(paths, block.Type()) {
+			continue
+		}
+		bodyTokens, changed, err := sortAttributesInBody(block.Body(), block.Type(), paths)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			continue
+		}
+		rebuilt, err := rebuildBlock(block, bodyTokens)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = rebuilt
+	}
+	return result, nil
+}
+
+// sortAttributesInBody returns the tokens of the body at path with its
+// attributes sorted if path is listed in paths, recursing into nested blocks.
+// Attributes are sorted among the positions held by attributes, so nested
+// blocks keep their place. Returns true if anything changed.
+func sortAttributesInBody(body *hclwrite.Body, path string, paths []string) (hclwrite.Tokens, bool, error) {
+	layout := splitBodyItems(body)
+	changed := false
+
+	for i, item := range layout.Items {
+		if item.Block == nil {
+			continue
+		}
+		nestedPath := path + "." + item.Name
+		if !hasPathPrefix(paths, nestedPath) {
+			continue
+		}
+		bodyTokens, nestedChanged, err := sortAttributesInBody(item.Block.Body(), nestedPath, paths)
+		if err != nil {
+			return nil, false, err
+		}
+		if !nestedChanged {
+			continue
+		}
+		item.Tokens, err = replaceItemBody(item, bodyTokens)
+		if err != nil {
+			return nil, false, err
+		}
+		layout.Items[i] = item
+		changed = true
+	}
+
+	if containsString(paths, path) {
+		var slots []int
+		var attrs []bodyItem
+		for i, item := range layout.Items {
+			if item.Attribute != nil {
+				slots = append(slots, i)
+				attrs = append(attrs, item)
+			}
+		}
+		sort.SliceStable(attrs, func(i, j int) bool {
+			return attrs[i].Name < attrs[j].Name
+		})
+
+		reordered := false
+		for k, slot := range slots {
+			if layout.Items[slot].Name != attrs[k].Name {
+				reordered = true
+			}
+		}
+		if reordered {
+			items := make([]bodyItem, len(layout.Items))
+			copy(items, layout.Items)
+			for k, slot := range slots {
+				items[slot] = attrs[k]
+			}
+			layout.Items = withoutBlankLines(items)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil, false, nil
+	}
+	return layout.BuildTokens(), true, nil
+}
+
+// hasPathPrefix reports whether any of paths is path or lies below it.
+func hasPathPrefix(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path || strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package sorter
+
+import (
+	"testing"
+)
+
+func TestAttributeSort(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "sorted with comments",
+			inputHCL: `# Deployment region
+region = "us-east-1"
+
+instance_count = 2 # per zone
+# Zones to deploy to
+zones = ["b", "a"]
+environment = "prod"
+# end of file
+`,
+			wantHCL: `environment    = "prod"
+instance_count = 2 # per zone
+# Deployment region
+region = "us-east-1"
+# Zones to deploy to
+zones = ["a", "b"]
+# end of file
+`,
+			sortOptions: SortOptions{SortAttributes: true, SortList: true},
+		},
+		{
+			name: "file header stays at the top",
+			inputHCL: `# Production settings
+# owned by ops
+
+region = "us-east-1"
+# Environment name
+environment = "prod"
+`,
+			wantHCL: `# Production settings
+# owned by ops
+
+# Environment name
+environment = "prod"
+region      = "us-east-1"
+`,
+			sortOptions: SortOptions{SortAttributes: true},
+		},
+		{
+			name: "original order is kept",
+			inputHCL: `region = "us-east-1"
+
+# Zones to deploy to
+zones = ["b", "a"]
+environment = "prod"
+`,
+			wantHCL: `region = "us-east-1"
+
+# Zones to deploy to
+zones       = ["a", "b"]
+environment = "prod"
+`,
+			sortOptions: SortOptions{SortList: true},
+		},
+		{
+			name: "already sorted keeps blank lines",
+			inputHCL: `a = 1
+
+b = {
+  key = "value"
+}
+`,
+			wantHCL: `a = 1
+
+b = {
+  key = "value"
+}
+`,
+			sortOptions: SortOptions{SortAttributes: true},
+		},
+		{
+			name: "attributes before blocks",
+			inputHCL: `b = 1
+a = 2
+resource "x" "y" {}
+`,
+			wantHCL: `a = 2
+b = 1
+resource "x" "y" {}
+`,
+			sortOptions: SortOptions{SortAttributes: true, SortBlocks: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sortString(t, tc.inputHCL, tc.sortOptions)
+			if got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+			if again := sortString(t, got, tc.sortOptions); again != got {
+				t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+}
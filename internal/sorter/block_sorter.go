@@ -1,6 +1,7 @@
 package sorter
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/hashicorp/hcl/v2/hclwrite"
@@ -19,55 +20,118 @@ var blockOrder = map[string]int{
 	// Add other known block types if necessary
 }
 
-// getBlockSortKey determines the primary sort key for a block based on its type.
-func getBlockSortKey(block *hclwrite.Block) int {
-	blockType := block.Type()
-	if order, ok := blockOrder[blockType]; ok {
-		return order
+// VariableOrder defines how variable blocks are ordered relative to each other.
+type VariableOrder string
+
+const (
+	// VariableOrderNone keeps variable blocks in their original order.
+	VariableOrderNone VariableOrder = ""
+	// VariableOrderRequiredFirst places variables without a default before those
+	// with one, sorting each group by name.
+	VariableOrderRequiredFirst VariableOrder = "required-first"
+)
+
+// ParseVariableOrder converts a command-line value into a VariableOrder.
+func ParseVariableOrder(value string) (VariableOrder, error) {
+	switch order := VariableOrder(value); order {
+	case VariableOrderNone, VariableOrderRequiredFirst:
+		return order, nil
+	}
+	return VariableOrderNone, fmt.Errorf("invalid variable order %q (want %q)", value, VariableOrderRequiredFirst)
+}
+
+// isRequiredVariable reports whether a variable block has no default value.
+func isRequiredVariable(block *hclwrite.Block) bool {
+	return block.Body().GetAttribute("default") == nil
+}
+
+// lessBlocks reports whether block a sorts before block b.
+func lessBlocks(a, b *hclwrite.Block, options SortOptions) bool {
+	profile := options.profile()
+	keyI := profile.blockRank(a.Type())
+	keyJ := profile.blockRank(b.Type())
+	if keyI != keyJ {
+		return keyI < keyJ
+	}
+	// Types may share a rank. Listed types of one rank are ordered by name,
+	// so that the blocks of each type stay together. Unlisted types keep
+	// their order, unless the blocks of one of them are sorted by label.
+	if a.Type() != b.Type() {
+		if keyI == unknownBlockRank && !(options.SortTypeName && profile.sortsUnlistedLabels()) {
+			return false
+		}
+		return a.Type() < b.Type()
+	}
+	if options.SortTypeName && profile.LabelSortTypes[a.Type()] {
+		labelsI := a.Labels()
+		labelsJ := b.Labels()
+		for k := 0; k < len(labelsI) && k < len(labelsJ); k++ {
+			if labelsI[k] != labelsJ[k] {
+				return labelsI[k] < labelsJ[k]
+			}
+		}
+	}
+	if options.VariableOrder == VariableOrderRequiredFirst && a.Type() == "variable" {
+		requiredI := isRequiredVariable(a)
+		requiredJ := isRequiredVariable(b)
+		if requiredI != requiredJ {
+			return requiredI
+		}
+		labelsI := a.Labels()
+		labelsJ := b.Labels()
+		if len(labelsI) > 0 && len(labelsJ) > 0 && labelsI[0] != labelsJ[0] {
+			return labelsI[0] < labelsJ[0]
+		}
 	}
-	// Assign a high number to unknown block types to sort them last.
-	return 99
+	return false // Maintain original order for same-keyed items or if type/name sort is off
 }
 
-// sortAndAddBlocksToBody sorts blocks from originalBody according to options
+// sortAndAddBlocksToBody sorts blocks according to options
 // and appends them to targetBody.
-func sortAndAddBlocksToBody(originalBody *hclwrite.Body, targetBody *hclwrite.Body, options SortOptions) {
-	blocks := originalBody.Blocks()
+func sortAndAddBlocksToBody(blocks []*hclwrite.Block, targetBody *hclwrite.Body, options SortOptions) error {
 	if len(blocks) == 0 {
-		return
+		return nil
 	}
 
-	blocksToSort := make([]*hclwrite.Block, len(blocks))
-	copy(blocksToSort, blocks)
-
-	sort.SliceStable(blocksToSort, func(i, j int) bool {
-		keyI := getBlockSortKey(blocksToSort[i])
-		keyJ := getBlockSortKey(blocksToSort[j])
-		if keyI != keyJ {
-			return keyI < keyJ
-		}
-		if options.SortTypeName && (blocksToSort[i].Type() == "resource" || blocksToSort[i].Type() == "data") {
-			labelsI := blocksToSort[i].Labels()
-			labelsJ := blocksToSort[j].Labels()
-			if len(labelsI) > 0 && len(labelsJ) > 0 {
-				if labelsI[0] != labelsJ[0] {
-					return labelsI[0] < labelsJ[0]
-				}
-				if len(labelsI) > 1 && len(labelsJ) > 1 {
-					if labelsI[1] != labelsJ[1] {
-						return labelsI[1] < labelsJ[1]
-					}
-				}
+	// Drop headers generated by a previous run; they are rebuilt below. Without
+	// header generation, comments that look like headers belong to the user.
+	blocksToSort := blocks
+	if generatesHeaders(options) {
+		titles := generatedHeaderTitles(blocks)
+		blocksToSort = make([]*hclwrite.Block, len(blocks))
+		for i, block := range blocks {
+			stripped, err := stripGeneratedHeaders(block, titles)
+			if err != nil {
+				return err
 			}
+			blocksToSort[i] = stripped
 		}
-		return false // Maintain original order for same-keyed items or if type/name sort is off
+	}
+
+	// Blocks glued together by directives move as one unit, sorted by their first member.
+	units := groupBlocksIntoUnits(blocksToSort)
+	sort.SliceStable(units, func(i, j int) bool {
+		return lessBlocks(units[i].Blocks[0], units[j].Blocks[0], options)
 	})
+	if options.Explain != nil {
+		explainBlockMoves(options.Explain, blocksToSort, units, options)
+	}
 
 	// Add sorted blocks to the new body
-	for i, block := range blocksToSort {
-		targetBody.AppendBlock(block)
-		if i < len(blocksToSort)-1 {
-			targetBody.AppendNewline() // Ensure newline between blocks
+	currentHeader := ""
+	for i, unit := range units {
+		if header := blockGroupHeader(unit.Blocks[0], options); header != "" && header != currentHeader {
+			targetBody.AppendUnstructuredTokens(headerTokens(header))
+			targetBody.AppendNewline()
+		}
+		currentHeader = blockGroupHeader(unit.Blocks[0], options)
+
+		for j, block := range unit.Blocks {
+			targetBody.AppendBlock(block)
+			if i < len(units)-1 || j < len(unit.Blocks)-1 {
+				targetBody.AppendNewline() // Ensure newline between blocks
+			}
 		}
 	}
+	return nil
 }
@@ -312,3 +312,150 @@ resource "a" "b" {
 		})
 	}
 }
+
+func TestVariableOrder(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "required first",
+			inputHCL: `variable "zone" {
+  default = "a"
+}
+
+variable "name" {}
+
+variable "count" {
+  default = 1
+}
+
+# the project
+variable "project" {}
+`,
+			wantHCL: `variable "name" {}
+
+# the project
+variable "project" {}
+
+variable "count" {
+  default = 1
+}
+
+variable "zone" {
+  default = "a"
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, VariableOrder: VariableOrderRequiredFirst},
+		},
+		{
+			name: "required first with group headers",
+			inputHCL: `terraform {}
+
+variable "zone" {
+  default = "a"
+}
+
+variable "name" {}
+
+output "x" {}
+`,
+			wantHCL: `terraform {}
+
+# --- Required variables ---
+
+variable "name" {}
+
+# --- Optional variables ---
+
+variable "zone" {
+  default = "a"
+}
+
+output "x" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, VariableOrder: VariableOrderRequiredFirst, VariableGroupHeaders: true},
+		},
+		{
+			name: "existing headers are rebuilt",
+			inputHCL: `# --- Optional variables ---
+
+variable "zone" {
+  default = "a"
+}
+# --- Required variables ---
+variable "name" {}
+`,
+			wantHCL: `# --- Required variables ---
+
+variable "name" {}
+
+# --- Optional variables ---
+
+variable "zone" {
+  default = "a"
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, VariableOrder: VariableOrderRequiredFirst, VariableGroupHeaders: true},
+		},
+		{
+			name: "original order by default",
+			inputHCL: `variable "zone" {
+  default = "a"
+}
+
+variable "name" {}
+`,
+			wantHCL: `variable "zone" {
+  default = "a"
+}
+
+variable "name" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hclFile, diags := parser.ParseHCL([]byte(tc.inputHCL), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+
+			sortedFile, err := Sort(hclFile, tc.sortOptions)
+			if err != nil {
+				t.Fatalf("Sort() unexpected error = %v", err)
+			}
+			if got := string(sortedFile.Bytes()); got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+
+			// Sorting the output again must not change it.
+			resortedFile, diags := parser.ParseHCL(sortedFile.Bytes(), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse sorted HCL: %v", diags)
+			}
+			resorted, err := Sort(resortedFile, tc.sortOptions)
+			if err != nil {
+				t.Fatalf("Sort() on sorted output unexpected error = %v", err)
+			}
+			if got := string(resorted.Bytes()); got != tc.wantHCL {
+				t.Errorf("Sort() is not idempotent\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+		})
+	}
+}
+
+func TestParseVariableOrder(t *testing.T) {
+	for _, value := range []string{"", "required-first"} {
+		if _, err := ParseVariableOrder(value); err != nil {
+			t.Errorf("ParseVariableOrder(%q) unexpected error = %v", value, err)
+		}
+	}
+	if _, err := ParseVariableOrder("optional-first"); err == nil {
+		t.Error("ParseVariableOrder(\"optional-first\") error = nil, want error")
+	}
+}
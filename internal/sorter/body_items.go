@@ -0,0 +1,252 @@
+package sorter
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// bodyItem is a single attribute or nested block of a body, together with the
+// detached comments and blank lines that precede it.
+type bodyItem struct {
+	Name      string // Attribute name or block type
+	Attribute *hclwrite.Attribute
+	Block     *hclwrite.Block
+	Tokens    hclwrite.Tokens
+}
+
+// bodyLayout is a body split into its items, keeping every token so that the
+// body can be rebuilt after the items have been reordered.
+type bodyLayout struct {
+	Preamble hclwrite.Tokens // Newline or comment on the line of the opening brace
+	Items    []bodyItem
+	Trailing hclwrite.Tokens // Comments and blank lines after the last item
+}
+
+// splitBodyItems splits a block body into its attributes and blocks in source order.
+func splitBodyItems(body *hclwrite.Body) bodyLayout {
+	return splitItems(body, true)
+}
+
+// splitFileItems splits the body of a file into its attributes and blocks in
+// source order. Unlike a block body, a file has no opening brace, so its first
+// comment belongs to the first item.
+func splitFileItems(body *hclwrite.Body) bodyLayout {
+	return splitItems(body, false)
+}
+
+// splitItems splits body into its items. inBlock tells whether the body is
+// enclosed in braces.
+func splitItems(body *hclwrite.Body, inBlock bool) bodyLayout {
+	var layout bodyLayout
+	bodyTokens := body.BuildTokens(nil)
+	if len(bodyTokens) == 0 {
+		return layout
+	}
+
+	// hclwrite tokens are shared between a body and its items, so the first
+	// token of each item identifies where it starts in the body.
+	starts := make(map[*hclwrite.Token]bodyItem)
+	for name, attr := range body.Attributes() {
+		tokens := attr.BuildTokens(nil)
+		if len(tokens) > 0 {
+			starts[tokens[0]] = bodyItem{Name: name, Attribute: attr, Tokens: tokens}
+		}
+	}
+	for _, block := range body.Blocks() {
+		tokens := block.BuildTokens(nil)
+		if len(tokens) > 0 {
+			starts[tokens[0]] = bodyItem{Name: block.Type(), Block: block, Tokens: tokens}
+		}
+	}
+
+	// A comment on the line of the opening brace is kept next to the brace, even
+	// when hclwrite attached it to the first item as a lead comment.
+	i := 1
+	layout.Preamble = bodyTokens[:1]
+	switch first := bodyTokens[0]; {
+	case !inBlock:
+		layout.Preamble = nil
+		i = 0
+	case first.Type == hclsyntax.TokenNewline:
+	case first.Type == hclsyntax.TokenComment:
+		if item, isItem := starts[first]; isItem && len(item.Tokens) > 1 {
+			delete(starts, first)
+			item.Tokens = item.Tokens[1:]
+			starts[item.Tokens[0]] = item
+		}
+	default:
+		layout.Preamble = nil
+		i = 0
+	}
+
+	var gap hclwrite.Tokens
+	for i < len(bodyTokens) {
+		item, isItem := starts[bodyTokens[i]]
+		if !isItem {
+			gap = append(gap, bodyTokens[i])
+			i++
+			continue
+		}
+		i += len(item.Tokens)
+		item.Tokens = append(append(hclwrite.Tokens{}, gap...), item.Tokens...)
+		layout.Items = append(layout.Items, item)
+		gap = nil
+	}
+	layout.Trailing = gap
+
+	return layout
+}
+
+// BuildTokens reassembles the body tokens with the items in their current order.
+// The first item never starts with a blank line and every item ends with a newline
+// so that items can be moved freely.
+func (l bodyLayout) BuildTokens() hclwrite.Tokens {
+	tokens := append(hclwrite.Tokens{}, l.Preamble...)
+	for i, item := range l.Items {
+		itemTokens := item.Tokens
+		if i == 0 {
+			itemTokens = removeLeadingNewlines(itemTokens)
+		}
+		tokens = append(tokens, itemTokens...)
+		tokens = append(tokens, ensureTrailingNewline(tokens)...)
+	}
+	tokens = append(tokens, l.Trailing...)
+	return tokens
+}
+
+// splitFilePreamble splits the comments leading the first item of a file that
+// are separated from it by a blank line, such as a license or a description of
+// the file, from the item. They head the file rather than the item, so they stay
+// at the top when the item moves.
+func splitFilePreamble(item bodyItem) (hclwrite.Tokens, bodyItem) {
+	end := 0
+	hasComment := false
+	for i, tok := range item.Tokens {
+		if tok.Type == hclsyntax.TokenComment {
+			hasComment = true
+			continue
+		}
+		if tok.Type != hclsyntax.TokenNewline {
+			break
+		}
+		if hasComment {
+			end = i + 1
+		}
+	}
+	if end == 0 {
+		return nil, item
+	}
+	preamble := item.Tokens[:end]
+	item.Tokens = item.Tokens[end:]
+	return preamble, item
+}
+
+// withoutBlankLines returns copies of the items with blank lines removed from
+// their leading comments. Blank lines group related items, which no longer
+// holds once the items have been reordered.
+func withoutBlankLines(items []bodyItem) []bodyItem {
+	result := make([]bodyItem, len(items))
+	for i, item := range items {
+		var tokens hclwrite.Tokens
+		leading := true
+		for _, tok := range item.Tokens {
+			if leading && tok.Type == hclsyntax.TokenNewline {
+				continue
+			}
+			if tok.Type != hclsyntax.TokenComment {
+				leading = false
+			}
+			tokens = append(tokens, tok)
+		}
+		item.Tokens = tokens
+		result[i] = item
+	}
+	return result
+}
+
+// leadComments returns the comments attached directly above a block.
+func leadComments(block *hclwrite.Block) hclwrite.Tokens {
+	var comments hclwrite.Tokens
+	for _, tok := range block.BuildTokens(nil) {
+		if tok.Type != hclsyntax.TokenComment {
+			break
+		}
+		comments = append(comments, tok)
+	}
+	return comments
+}
+
+// rebuildBlock returns a new block with the same header as block but with the
+// given tokens as its body. The block is re-parsed so that its attributes and
+// nested blocks stay accessible through the hclwrite API.
+func rebuildBlock(block *hclwrite.Block, bodyTokens hclwrite.Tokens) (*hclwrite.Block, error) {
+	newTokens, err := spliceBlockBody(block, bodyTokens)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlockTokens(newTokens, block.Type())
+}
+
+// spliceBlockBody returns the tokens of block with its body replaced by bodyTokens.
+func spliceBlockBody(block *hclwrite.Block, bodyTokens hclwrite.Tokens) (hclwrite.Tokens, error) {
+	blockTokens := block.BuildTokens(nil)
+	oldBodyTokens := block.Body().BuildTokens(nil)
+
+	// Locate the original body within the block tokens.
+	start := -1
+	if len(oldBodyTokens) > 0 {
+		for i, tok := range blockTokens {
+			if tok == oldBodyTokens[0] {
+				start = i
+				break
+			}
+		}
+	} else {
+		for i, tok := range blockTokens {
+			if tok.Type == hclsyntax.TokenOBrace {
+				start = i + 1
+				break
+			}
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("could not locate the body of %s block", block.Type())
+	}
+
+	var newTokens hclwrite.Tokens
+	newTokens = append(newTokens, blockTokens[:start]...)
+	newTokens = append(newTokens, bodyTokens...)
+	newTokens = append(newTokens, blockTokens[start+len(oldBodyTokens):]...)
+	return newTokens, nil
+}
+
+// replaceItemBody returns the tokens of a nested block item with the block body
+// replaced by bodyTokens. The comments and blank lines before the block are kept.
+func replaceItemBody(item bodyItem, bodyTokens hclwrite.Tokens) (hclwrite.Tokens, error) {
+	blockTokens := item.Block.BuildTokens(nil)
+	gapLen := len(item.Tokens) - len(blockTokens)
+	if gapLen < 0 {
+		return nil, fmt.Errorf("could not locate the %s block in its parent body", item.Name)
+	}
+	newBlockTokens, err := spliceBlockBody(item.Block, bodyTokens)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(hclwrite.Tokens{}, item.Tokens[:gapLen]...), newBlockTokens...), nil
+}
+
+// parseBlockTokens parses tokens holding exactly one block and returns that block.
+func parseBlockTokens(tokens hclwrite.Tokens, blockType string) (*hclwrite.Block, error) {
+	file, diags := hclwrite.ParseConfig(tokens.Bytes(), blockType, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to rebuild %s block: %v", blockType, diags)
+	}
+	blocks := file.Body().Blocks()
+	if len(blocks) != 1 {
+		return nil, fmt.Errorf("failed to rebuild %s block: got %d blocks", blockType, len(blocks))
+	}
+	return blocks[0], nil
+}
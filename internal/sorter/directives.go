@@ -0,0 +1,113 @@
+package sorter
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Directives recognized in comments.
+const (
+	ignoreDirective       = "tfsort:ignore"
+	keepWithNextDirective = "tfsort:keep-with-next"
+	groupDirectivePrefix  = "tfsort:group="
+)
+
+// commentText returns the text of a comment token without its comment markers
+// and surrounding whitespace.
+func commentText(tok *hclwrite.Token) string {
+	text := strings.TrimSpace(string(tok.Bytes))
+	switch {
+	case strings.HasPrefix(text, "//"):
+		text = strings.TrimPrefix(text, "//")
+	case strings.HasPrefix(text, "#"):
+		text = strings.TrimPrefix(text, "#")
+	case strings.HasPrefix(text, "/*"):
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	}
+	return strings.TrimSpace(text)
+}
+
+// blockDirectives holds the directives found in the lead comments of a block.
+type blockDirectives struct {
+	KeepWithNext bool
+	Group        string
+}
+
+// parseBlockDirectives reads the keep-together directives from the lead comments of a block.
+func parseBlockDirectives(block *hclwrite.Block) blockDirectives {
+	var directives blockDirectives
+	for _, tok := range leadComments(block) {
+		if tok.Type != hclsyntax.TokenComment {
+			continue
+		}
+		text := commentText(tok)
+		switch {
+		case text == keepWithNextDirective:
+			directives.KeepWithNext = true
+		case strings.HasPrefix(text, groupDirectivePrefix):
+			directives.Group = strings.TrimSpace(strings.TrimPrefix(text, groupDirectivePrefix))
+		}
+	}
+	return directives
+}
+
+// blockUnit is a run of blocks that move together during block sorting.
+type blockUnit struct {
+	Blocks []*hclwrite.Block
+}
+
+// groupBlocksIntoUnits glues blocks into units following their directives.
+// A block marked `tfsort:keep-with-next` is glued to the block after it, and
+// blocks sharing a `tfsort:group=<name>` directive are glued together in their
+// original order. Every other block forms a unit of its own. A block kept with
+// a later member of a group joins the group with it, right before that member.
+func groupBlocksIntoUnits(blocks []*hclwrite.Block) []*blockUnit {
+	var units []*blockUnit
+	groups := make(map[string]*blockUnit)
+	var previous *blockUnit
+	keepWithPrevious := false
+
+	for _, block := range blocks {
+		directives := parseBlockDirectives(block)
+
+		var unit *blockUnit
+		switch {
+		case keepWithPrevious && directives.Group != "" && groups[directives.Group] != nil && groups[directives.Group] != previous:
+			unit = groups[directives.Group]
+			unit.Blocks = append(unit.Blocks, previous.Blocks...)
+			units = removeUnit(units, previous)
+			for name, group := range groups {
+				if group == previous {
+					groups[name] = unit
+				}
+			}
+		case keepWithPrevious:
+			unit = previous
+		case directives.Group != "" && groups[directives.Group] != nil:
+			unit = groups[directives.Group]
+		default:
+			unit = &blockUnit{}
+			units = append(units, unit)
+		}
+		unit.Blocks = append(unit.Blocks, block)
+		if directives.Group != "" && groups[directives.Group] == nil {
+			groups[directives.Group] = unit
+		}
+
+		previous = unit
+		keepWithPrevious = directives.KeepWithNext
+	}
+	return units
+}
+
+// removeUnit returns units without unit.
+func removeUnit(units []*blockUnit, unit *blockUnit) []*blockUnit {
+	for i, u := range units {
+		if u == unit {
+			return append(units[:i], units[i+1:]...)
+		}
+	}
+	return units
+}
@@ -0,0 +1,219 @@
+package sorter
+
+import (
+	"testing"
+
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+func TestKeepTogetherDirectives(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "keep-with-next glues blocks",
+			inputHCL: `output "id" {
+  value = aws_instance.web.id
+}
+
+# tfsort:keep-with-next
+variable "name" {}
+
+output "name" {
+  value = var.name
+}
+
+variable "zone" {}
+`,
+			wantHCL: `# tfsort:keep-with-next
+variable "name" {}
+
+output "name" {
+  value = var.name
+}
+
+variable "zone" {}
+
+output "id" {
+  value = aws_instance.web.id
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true},
+		},
+		{
+			name: "keep-with-next chains",
+			inputHCL: `resource "b" "x" {}
+
+// tfsort:keep-with-next
+resource "c" "x" {}
+
+# tfsort:keep-with-next
+data "d" "x" {}
+
+resource "e" "x" {}
+
+resource "a" "x" {}
+`,
+			wantHCL: `resource "a" "x" {}
+
+resource "b" "x" {}
+
+// tfsort:keep-with-next
+resource "c" "x" {}
+
+# tfsort:keep-with-next
+data "d" "x" {}
+
+resource "e" "x" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true},
+		},
+		{
+			name: "group collects members at the first position",
+			inputHCL: `resource "z" "role" {}
+
+# tfsort:group=iam
+resource "y" "policy" {}
+
+resource "a" "bucket" {}
+
+# tfsort:group=iam
+output "policy" {
+  value = 1
+}
+`,
+			wantHCL: `resource "a" "bucket" {}
+
+# tfsort:group=iam
+resource "y" "policy" {}
+
+# tfsort:group=iam
+output "policy" {
+  value = 1
+}
+
+resource "z" "role" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true},
+		},
+		{
+			name: "keep-with-next before the first group member joins the group",
+			inputHCL: `resource "z" "role" {}
+
+# tfsort:keep-with-next
+resource "x" "attachment" {}
+
+# tfsort:group=iam
+resource "y" "policy" {}
+
+resource "a" "bucket" {}
+
+# tfsort:group=iam
+resource "w" "user" {}
+`,
+			wantHCL: `resource "a" "bucket" {}
+
+# tfsort:keep-with-next
+resource "x" "attachment" {}
+
+# tfsort:group=iam
+resource "y" "policy" {}
+
+# tfsort:group=iam
+resource "w" "user" {}
+
+resource "z" "role" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true},
+		},
+		{
+			name: "keep-with-next before a later group member moves with that member",
+			inputHCL: `# tfsort:group=iam
+resource "y" "policy" {}
+
+resource "z" "role" {}
+
+# tfsort:keep-with-next
+resource "x" "attachment" {}
+
+# tfsort:group=iam
+resource "w" "user" {}
+
+resource "a" "bucket" {}
+`,
+			wantHCL: `resource "a" "bucket" {}
+
+# tfsort:group=iam
+resource "y" "policy" {}
+
+# tfsort:keep-with-next
+resource "x" "attachment" {}
+
+# tfsort:group=iam
+resource "w" "user" {}
+
+resource "z" "role" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true},
+		},
+		{
+			name: "unit header comes from the first member",
+			inputHCL: `output "b" {
+  value = 1
+}
+
+# tfsort:keep-with-next
+variable "a" {}
+
+output "a" {
+  value = var.a
+}
+`,
+			wantHCL: `# --- Variables ---
+
+# tfsort:keep-with-next
+variable "a" {}
+
+output "a" {
+  value = var.a
+}
+
+# --- Outputs ---
+
+output "b" {
+  value = 1
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SectionHeaders: SectionHeadersBlockType},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sortString(t, tc.inputHCL, tc.sortOptions)
+			if got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+			// Sorting the output again must not change it.
+			if again := sortString(t, got, tc.sortOptions); again != got {
+				t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+}
+
+func sortString(t *testing.T, input string, options SortOptions) string {
+	t.Helper()
+	hclFile, diags := parser.ParseHCL([]byte(input), "test.tf")
+	if diags.HasErrors() {
+		t.Fatalf("Failed to parse input HCL: %v", diags)
+	}
+	sortedFile, err := Sort(hclFile, options)
+	if err != nil {
+		t.Fatalf("Sort() unexpected error = %v", err)
+	}
+	return string(sortedFile.Bytes())
+}
@@ -0,0 +1,243 @@
+package sorter
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Reasons a list is left unsorted, recorded in ListChange.Skipped.
+const (
+	SkipIgnoreDirective = "tfsort:ignore directive"
+	SkipTooFewElements  = "fewer than two elements"
+	SkipOutOfScope      = "outside the list scopes of the profile"
+	SkipCommentLayout   = "comments that cannot move with their elements"
+	SkipOrderSensitive  = "argument of an order-sensitive function"
+	SkipNotListLiteral  = "not a list literal"
+)
+
+// Explanation records why Sort changed a file: the top-level blocks it moved,
+// with the keys they were sorted by, and the lists it sorted or left alone.
+type Explanation struct {
+	Blocks []BlockMove
+	Lists  []ListChange
+}
+
+// BlockMove is a top-level block that sorting moved. Blocks that only shift
+// because others moved around them are not recorded.
+type BlockMove struct {
+	Address string // Block type and labels joined with dots, such as resource.aws_s3_bucket.logs
+	// Name is the address, followed by #n for the nth of several blocks with
+	// the same address in the original file, such as locals#2.
+	Name string
+	// Before is the name of the block it was moved in front of, or empty if
+	// it was moved to the end, after the block named by After. Blocks glued
+	// to it are not named.
+	Before, After string
+	// Rank is the rank of the block type in the block order of the profile.
+	// Types the profile does not list share the last rank.
+	Rank int
+	// Labels are the labels the block was sorted by among the blocks of its
+	// type, or nil if blocks of its type keep their order.
+	Labels []string
+	// Required is "required" or "optional" when variables are ordered
+	// required-first, and empty otherwise.
+	Required string
+	// GluedTo is the name of the block a directive glues this block to. The
+	// block moved with it and was sorted by its key.
+	GluedTo string
+	// Directive is the directive that glues the block, tfsort:keep-with-next
+	// or tfsort:group=<name>, if GluedTo is set.
+	Directive string
+	// Occurrence counts the blocks with the same address before the block in
+	// the original file, to tell apart blocks such as repeated locals blocks.
+	Occurrence int
+	// NewIndex is the index of the block among the top-level blocks after sorting.
+	NewIndex int
+	// WithinType is set if the block changed places with a block of its own
+	// type, so that it is out of order among the blocks of its type rather
+	// than only out of block type order.
+	WithinType bool
+}
+
+// ListChange is a list that sorting sorted, or left alone although it is a
+// candidate for sorting.
+type ListChange struct {
+	// Path joins the address of the top-level block, the types of the nested
+	// blocks and the attribute name with dots, such as
+	// resource.aws_security_group.web.ingress.cidr_blocks.
+	Path string
+	// Before and After are the elements in their original and sorted order.
+	// After is nil if the list was skipped.
+	Before, After []string
+	// Skipped is the reason the list was left alone, or empty if it was sorted.
+	Skipped string
+}
+
+// listRecorder receives the outcome of every list the list sorter looks at:
+// its elements before and after sorting, or the reason it was skipped. A nil
+// listRecorder records nothing.
+type listRecorder func(before, after []string, skipped string)
+
+func (r listRecorder) record(before, after []string, skipped string) {
+	if r != nil {
+		r(before, after, skipped)
+	}
+}
+
+// elementTexts returns the source text of list elements, without comments,
+// line breaks or the commas separating them.
+func elementTexts(elements []listElement) []string {
+	texts := make([]string, len(elements))
+	for i, elem := range elements {
+		var expr hclwrite.Tokens
+		for _, tok := range elem.Tokens {
+			if tok.Type != hclsyntax.TokenComment && tok.Type != hclsyntax.TokenNewline {
+				expr = append(expr, tok)
+			}
+		}
+		for len(expr) > 0 && expr[len(expr)-1].Type == hclsyntax.TokenComma {
+			expr = expr[:len(expr)-1]
+		}
+		if len(expr) > 0 {
+			expr[0] = &hclwrite.Token{Type: expr[0].Type, Bytes: expr[0].Bytes}
+		}
+		texts[i] = string(expr.Bytes())
+	}
+	return texts
+}
+
+// isIndexBracket reports whether the bracket at tokens[i] opens an index, as
+// in var.subnets[0], rather than a list.
+func isIndexBracket(tokens hclwrite.Tokens, i int) bool {
+	if i == 0 {
+		return false
+	}
+	switch tokens[i-1].Type {
+	case hclsyntax.TokenIdent, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenStar:
+		return true
+	}
+	return false
+}
+
+// explainBlockMoves records the blocks that moved when blocks were sorted into
+// sorted. The blocks of the longest run that kept its relative order stay, and
+// every other block moved.
+func explainBlockMoves(explain *Explanation, blocks []*hclwrite.Block, units []*blockUnit, options SortOptions) {
+	from := make(map[*hclwrite.Block]int, len(blocks))
+	occurrence := make(map[*hclwrite.Block]int, len(blocks))
+	seen := make(map[string]int)
+	for i, block := range blocks {
+		from[block] = i
+		occurrence[block] = seen[blockAddress(block)]
+		seen[blockAddress(block)]++
+	}
+	name := func(block *hclwrite.Block) string {
+		if address := blockAddress(block); seen[address] > 1 {
+			return fmt.Sprintf("%s#%d", address, occurrence[block]+1)
+		}
+		return blockAddress(block)
+	}
+	var sorted []*hclwrite.Block
+	unitOf := make(map[*hclwrite.Block]*blockUnit)
+	for _, unit := range units {
+		for _, block := range unit.Blocks {
+			sorted = append(sorted, block)
+			unitOf[block] = unit
+		}
+	}
+
+	// The longest increasing subsequence of original positions, in sorted order.
+	positions := make([]int, len(sorted))
+	for i, block := range sorted {
+		positions[i] = from[block]
+	}
+	stays := make([]bool, len(sorted))
+	for _, i := range longestIncreasing(positions) {
+		stays[i] = true
+	}
+
+	profile := options.profile()
+	for to, block := range sorted {
+		if stays[to] {
+			continue
+		}
+		move := BlockMove{
+			Address:    blockAddress(block),
+			Name:       name(block),
+			Rank:       profile.blockRank(block.Type()),
+			Occurrence: occurrence[block],
+			NewIndex:   to,
+		}
+		for other, otherBlock := range sorted {
+			if otherBlock.Type() == block.Type() && (other < to) != (from[otherBlock] < from[block]) {
+				move.WithinType = true
+				break
+			}
+		}
+		// Name the neighbours outside the unit of the block, which moved with it.
+		unit := unitOf[block]
+		next, previous := to+1, to-1
+		for next < len(sorted) && unitOf[sorted[next]] == unit {
+			next++
+		}
+		for previous >= 0 && unitOf[sorted[previous]] == unit {
+			previous--
+		}
+		if next < len(sorted) {
+			move.Before = name(sorted[next])
+		} else if previous >= 0 {
+			move.After = name(sorted[previous])
+		}
+		if options.SortTypeName && profile.LabelSortTypes[block.Type()] {
+			move.Labels = block.Labels()
+		}
+		if options.VariableOrder == VariableOrderRequiredFirst && block.Type() == "variable" {
+			move.Required = "optional"
+			if isRequiredVariable(block) {
+				move.Required = "required"
+			}
+			move.Labels = block.Labels()
+		}
+		if leader := unit.Blocks[0]; leader != block {
+			move.GluedTo = name(leader)
+			// A keep-with-next directive on the block before it takes
+			// precedence over a group directive, as when grouping.
+			move.Directive = keepWithNextDirective
+			if i := from[block]; i == 0 || !parseBlockDirectives(blocks[i-1]).KeepWithNext {
+				move.Directive = groupDirectivePrefix + parseBlockDirectives(block).Group
+			}
+		}
+		explain.Blocks = append(explain.Blocks, move)
+	}
+}
+
+// longestIncreasing returns the indexes of a longest strictly increasing
+// subsequence of values, in order. Of subsequences of the same length, it
+// prefers those with smaller values, so that the blocks that come first in the
+// original file stay and the blocks sorted before them move.
+func longestIncreasing(values []int) []int {
+	length := make([]int, len(values))
+	previous := make([]int, len(values))
+	best := -1
+	for i := range values {
+		length[i], previous[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if values[j] >= values[i] {
+				continue
+			}
+			if length[j]+1 > length[i] || length[j]+1 == length[i] && values[j] < values[previous[i]] {
+				length[i], previous[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] || length[i] == length[best] && values[i] < values[best] {
+			best = i
+		}
+	}
+	var indexes []int
+	for i := best; i >= 0; i = previous[i] {
+		indexes = append([]int{i}, indexes...)
+	}
+	return indexes
+}
@@ -0,0 +1,250 @@
+package sorter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+func TestSortExplain(t *testing.T) {
+	testCases := []struct {
+		name       string
+		inputHCL   string
+		options    SortOptions
+		wantBlocks []BlockMove
+		wantLists  []ListChange
+	}{
+		{
+			name: "moved blocks",
+			inputHCL: `resource "aws_s3_bucket" "b" {}
+resource "aws_iam_role" "a" {}
+resource "aws_security_group" "web" {}
+variable "x" {}
+`,
+			options: SortOptions{SortBlocks: true, SortTypeName: true},
+			wantBlocks: []BlockMove{
+				{Address: "variable.x", Name: "variable.x", Before: "resource.aws_iam_role.a", Rank: 3},
+				{Address: "resource.aws_iam_role.a", Name: "resource.aws_iam_role.a", Before: "resource.aws_s3_bucket.b", Rank: 7, Labels: []string{"aws_iam_role", "a"}, NewIndex: 1, WithinType: true},
+			},
+		},
+		{
+			name: "block moved to the end",
+			inputHCL: `output "x" {}
+variable "a" {}
+variable "b" {}
+`,
+			options:    SortOptions{SortBlocks: true},
+			wantBlocks: []BlockMove{{Address: "output.x", Name: "output.x", After: "variable.b", Rank: 8, NewIndex: 2}},
+		},
+		{
+			name: "required variables",
+			inputHCL: `variable "b" {
+  default = 1
+}
+variable "a" {}
+`,
+			options:    SortOptions{SortBlocks: true, VariableOrder: VariableOrderRequiredFirst},
+			wantBlocks: []BlockMove{{Address: "variable.a", Name: "variable.a", Before: "variable.b", Rank: 3, Labels: []string{"a"}, Required: "required", WithinType: true}},
+		},
+		{
+			name: "glued blocks",
+			inputHCL: `resource "c" "c" {}
+resource "d" "d" {}
+resource "e" "e" {}
+# tfsort:keep-with-next
+resource "a" "a" {}
+output "a" {}
+`,
+			options: SortOptions{SortBlocks: true, SortTypeName: true},
+			wantBlocks: []BlockMove{
+				{Address: "resource.a.a", Name: "resource.a.a", Before: "resource.c.c", Rank: 7, Labels: []string{"a", "a"}, WithinType: true},
+				{Address: "output.a", Name: "output.a", Before: "resource.c.c", Rank: 8, GluedTo: "resource.a.a", Directive: "tfsort:keep-with-next", NewIndex: 1},
+			},
+		},
+		{
+			name: "grouped blocks",
+			inputHCL: `# tfsort:group=net
+variable "b" {}
+output "o" {}
+# tfsort:group=net
+output "a" {}
+`,
+			options: SortOptions{SortBlocks: true, SortTypeName: true},
+			wantBlocks: []BlockMove{
+				{Address: "output.a", Name: "output.a", Before: "output.o", Rank: 8, GluedTo: "variable.b", Directive: "tfsort:group=net", NewIndex: 1, WithinType: true},
+			},
+		},
+		{
+			name: "blocks with the same address",
+			inputHCL: `resource "x" "x" {}
+variable "a" {}
+output "o" {}
+variable "a" {}
+`,
+			options: SortOptions{SortBlocks: true, SortTypeName: true},
+			wantBlocks: []BlockMove{
+				{Address: "variable.a", Name: "variable.a#1", Before: "variable.a#2", Rank: 3},
+				{Address: "variable.a", Name: "variable.a#2", Before: "resource.x.x", Rank: 3, Occurrence: 1, NewIndex: 1},
+			},
+		},
+		{
+			name: "blocks without labels",
+			inputHCL: `moved {
+  from = a.b
+  to   = a.c
+}
+variable "v" {}
+moved {
+  from = a.d
+  to   = a.e
+}
+`,
+			options: SortOptions{SortBlocks: true, SortTypeName: true},
+			wantBlocks: []BlockMove{
+				{Address: "variable.v", Name: "variable.v", Before: "moved#1", Rank: 3},
+			},
+		},
+		{
+			name: "sorted and skipped lists",
+			inputHCL: `resource "aws_security_group" "web" {
+  ingress {
+    cidr_blocks = ["b", "a"]
+  }
+  ids      = concat([2, 1], [var.ids[0]])
+  ignored  = [ # tfsort:ignore
+    "b", "a"
+  ]
+  one      = ["a"]
+  sorted   = ["a", "b"]
+}
+`,
+			options: SortOptions{SortList: true},
+			wantLists: []ListChange{
+				{Path: "resource.aws_security_group.web.ids", Before: []string{"2", "1"}, After: []string{"1", "2"}},
+				{Path: "resource.aws_security_group.web.ids", Before: []string{"var.ids[0]"}, Skipped: SkipTooFewElements},
+				{Path: "resource.aws_security_group.web.ignored", Skipped: SkipIgnoreDirective},
+				{Path: "resource.aws_security_group.web.one", Before: []string{`"a"`}, Skipped: SkipTooFewElements},
+				{Path: "resource.aws_security_group.web.ingress.cidr_blocks", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}},
+			},
+		},
+		{
+			name: "list elements without their comments",
+			inputHCL: `locals {
+  a = [
+    "z", # about z
+    # about a
+    "a",
+    var.x /* about x */,
+  ]
+}
+`,
+			options: SortOptions{SortList: true},
+			wantLists: []ListChange{
+				{Path: "locals.a", Before: []string{`"z"`, `"a"`, "var.x"}, After: []string{`"a"`, `"z"`, "var.x"}},
+			},
+		},
+		{
+			name: "for expressions",
+			inputHCL: `locals {
+  ids   = [for s in var.subnets : s.id]
+  names = [for k, a in var.m : a]
+}
+`,
+			options: SortOptions{SortList: true},
+			wantLists: []ListChange{
+				{Path: "locals.ids", Skipped: SkipNotListLiteral},
+				{Path: "locals.names", Skipped: SkipNotListLiteral},
+			},
+		},
+		{
+			name: "lists outside the list scopes",
+			inputHCL: `build {
+  sources = ["b", "a"]
+  provisioner "shell" {
+    inline = ["b", "a"]
+  }
+}
+`,
+			options: SortOptions{SortList: true, Profile: PackerProfile},
+			wantLists: []ListChange{
+				{Path: "build.sources", Before: []string{`"b"`, `"a"`}, After: []string{`"a"`, `"b"`}},
+				{Path: "build.provisioner.inline", Before: []string{`"b"`, `"a"`}, Skipped: SkipOutOfScope},
+			},
+		},
+		{
+			name: "lists kept for their comments",
+			inputHCL: `locals {
+  inline   = ["b", /* about a */ "a"]
+  trailing = [
+    "b",
+    "a",
+    # about nothing
+  ]
+}
+`,
+			options: SortOptions{SortList: true},
+			wantLists: []ListChange{
+				{Path: "locals.inline", Skipped: SkipCommentLayout},
+				{Path: "locals.trailing", Skipped: SkipCommentLayout},
+			},
+		},
+		{
+			name: "lists passed to order-sensitive functions",
+			inputHCL: `locals {
+  first = element(["b", "a"], 0)
+  tags  = zipmap(["b", "a"], concat(["d", "c"]))
+  ids   = concat(["f", "e"], reverse(["h", "g"]))
+}
+`,
+			options: SortOptions{SortList: true},
+			wantLists: []ListChange{
+				{Path: "locals.first", Skipped: SkipOrderSensitive},
+				{Path: "locals.ids", Before: []string{`"f"`, `"e"`}, After: []string{`"e"`, `"f"`}},
+				{Path: "locals.ids", Skipped: SkipOrderSensitive},
+				{Path: "locals.tags", Skipped: SkipOrderSensitive},
+				{Path: "locals.tags", Skipped: SkipOrderSensitive},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, diags := parser.ParseHCL([]byte(tc.inputHCL), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+			explanation := &Explanation{}
+			tc.options.Explain = explanation
+			if _, err := Sort(file, tc.options); err != nil {
+				t.Fatalf("Sort() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(explanation.Blocks, tc.wantBlocks) {
+				t.Errorf("Blocks =\n%+v\nwant:\n%+v", explanation.Blocks, tc.wantBlocks)
+			}
+			if !reflect.DeepEqual(explanation.Lists, tc.wantLists) {
+				t.Errorf("Lists =\n%+v\nwant:\n%+v", explanation.Lists, tc.wantLists)
+			}
+		})
+	}
+}
+
+func TestSortSingleListIfPossibleNotListLiteral(t *testing.T) {
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOParen, Bytes: []byte("(")},
+		{Type: hclsyntax.TokenCParen, Bytes: []byte(")")},
+	}
+	var got []ListChange
+	record := func(before, after []string, skipped string) {
+		got = append(got, ListChange{Before: before, After: after, Skipped: skipped})
+	}
+	if _, sorted := sortSingleListIfPossible(tokens, record); sorted {
+		t.Errorf("sortSingleListIfPossible() sorted %q", tokens.Bytes())
+	}
+	want := []ListChange{{Skipped: SkipNotListLiteral}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded %+v, want %+v", got, want)
+	}
+}
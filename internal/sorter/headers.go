@@ -0,0 +1,164 @@
+package sorter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// SectionHeaderStyle defines which section header comments are generated
+// between groups of sorted blocks.
+type SectionHeaderStyle string
+
+const (
+	// SectionHeadersNone generates no section headers.
+	SectionHeadersNone SectionHeaderStyle = ""
+	// SectionHeadersBlockType generates a header per block type, such as `# --- Variables ---`.
+	SectionHeadersBlockType SectionHeaderStyle = "block-type"
+	// SectionHeadersPrefix generates a header per resource type prefix, such as
+	// `# --- aws_iam ---`, and a header per block type for other blocks.
+	SectionHeadersPrefix SectionHeaderStyle = "prefix"
+)
+
+// ParseSectionHeaderStyle converts a command-line value into a SectionHeaderStyle.
+func ParseSectionHeaderStyle(value string) (SectionHeaderStyle, error) {
+	switch style := SectionHeaderStyle(value); style {
+	case SectionHeadersNone, SectionHeadersBlockType, SectionHeadersPrefix:
+		return style, nil
+	}
+	return SectionHeadersNone, fmt.Errorf("invalid section header style %q (want %q or %q)", value, SectionHeadersBlockType, SectionHeadersPrefix)
+}
+
+// Titles of the variable group headers generated by tfsort.
+const (
+	requiredVariablesHeader = "Required variables"
+	optionalVariablesHeader = "Optional variables"
+)
+
+// blockTypeHeaders holds the header titles of the standard block types.
+// Other block types use the block type itself as the title.
+var blockTypeHeaders = map[string]string{
+	"terraform": "Terraform",
+	"provider":  "Providers",
+	"variable":  "Variables",
+	"locals":    "Locals",
+	"data":      "Data sources",
+	"module":    "Modules",
+	"resource":  "Resources",
+	"output":    "Outputs",
+}
+
+// blockTypeHeader returns the header title for a block type.
+func blockTypeHeader(blockType string) string {
+	if title, ok := blockTypeHeaders[blockType]; ok {
+		return title
+	}
+	return blockType
+}
+
+// typePrefix returns the provider and service prefix of a resource type,
+// e.g. "aws_iam" for "aws_iam_role".
+func typePrefix(typeName string) string {
+	parts := strings.SplitN(typeName, "_", 3)
+	if len(parts) < 2 {
+		return typeName
+	}
+	return parts[0] + "_" + parts[1]
+}
+
+// blockGroupHeader returns the section header to print above a block, or an
+// empty string if the block does not belong to a headed group.
+func blockGroupHeader(block *hclwrite.Block, options SortOptions) string {
+	if options.VariableGroupHeaders && options.VariableOrder == VariableOrderRequiredFirst && block.Type() == "variable" {
+		if isRequiredVariable(block) {
+			return requiredVariablesHeader
+		}
+		return optionalVariablesHeader
+	}
+
+	switch options.SectionHeaders {
+	case SectionHeadersBlockType:
+		return blockTypeHeader(block.Type())
+	case SectionHeadersPrefix:
+		if labels := block.Labels(); block.Type() == "resource" && len(labels) > 0 {
+			return typePrefix(labels[0])
+		}
+		return blockTypeHeader(block.Type())
+	}
+	return ""
+}
+
+// generatesHeaders reports whether options generate any section headers.
+func generatesHeaders(options SortOptions) bool {
+	return options.SectionHeaders != SectionHeadersNone ||
+		options.VariableGroupHeaders && options.VariableOrder == VariableOrderRequiredFirst
+}
+
+// generatedHeaderTitles returns every header title tfsort may generate for the
+// given blocks under any option, so that headers from a previous run can be
+// recognized without touching other comments.
+func generatedHeaderTitles(blocks []*hclwrite.Block) map[string]bool {
+	titles := map[string]bool{
+		requiredVariablesHeader: true,
+		optionalVariablesHeader: true,
+	}
+	for _, title := range blockTypeHeaders {
+		titles[title] = true
+	}
+	for _, block := range blocks {
+		titles[blockTypeHeader(block.Type())] = true
+		if labels := block.Labels(); block.Type() == "resource" && len(labels) > 0 {
+			titles[typePrefix(labels[0])] = true
+		}
+	}
+	return titles
+}
+
+// headerTokens returns the comment tokens of a section header.
+func headerTokens(title string) hclwrite.Tokens {
+	return hclwrite.Tokens{
+		{Type: hclsyntax.TokenComment, Bytes: []byte("# --- " + title + " ---\n")},
+	}
+}
+
+// isGeneratedHeader reports whether a comment token is a section header with one of the given titles.
+func isGeneratedHeader(tok *hclwrite.Token, titles map[string]bool) bool {
+	if tok.Type != hclsyntax.TokenComment {
+		return false
+	}
+	comment := strings.TrimSpace(string(tok.Bytes))
+	if !strings.HasPrefix(comment, "# ---") || !strings.HasSuffix(comment, "---") {
+		return false
+	}
+	title := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(comment, "# ---"), "---"))
+	return titles[title]
+}
+
+// stripGeneratedHeaders returns the block without generated section headers in
+// its lead comments. Headers separated from a block by a blank line are not part
+// of the block and are dropped when the file is rebuilt; this handles headers
+// that ended up directly above a block.
+func stripGeneratedHeaders(block *hclwrite.Block, titles map[string]bool) (*hclwrite.Block, error) {
+	lead := leadComments(block)
+	hasHeader := false
+	for _, tok := range lead {
+		if isGeneratedHeader(tok, titles) {
+			hasHeader = true
+			break
+		}
+	}
+	if !hasHeader {
+		return block, nil
+	}
+
+	var tokens hclwrite.Tokens
+	for i, tok := range block.BuildTokens(nil) {
+		if i < len(lead) && isGeneratedHeader(tok, titles) {
+			continue
+		}
+		tokens = append(tokens, tok)
+	}
+	return parseBlockTokens(tokens, block.Type())
+}
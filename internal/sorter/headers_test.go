@@ -0,0 +1,184 @@
+package sorter
+
+import (
+	"testing"
+
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+func TestSectionHeaders(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "block type headers",
+			inputHCL: `resource "aws_s3_bucket" "logs" {}
+variable "region" {}
+variable "name" {}
+data "aws_caller_identity" "current" {}
+`,
+			wantHCL: `# --- Variables ---
+
+variable "region" {}
+
+variable "name" {}
+
+# --- Data sources ---
+
+data "aws_caller_identity" "current" {}
+
+# --- Resources ---
+
+resource "aws_s3_bucket" "logs" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SectionHeaders: SectionHeadersBlockType},
+		},
+		{
+			name: "prefix headers",
+			inputHCL: `resource "aws_s3_bucket" "logs" {}
+resource "aws_iam_role" "app" {}
+resource "aws_iam_policy" "app" {}
+output "arn" {}
+`,
+			wantHCL: `# --- aws_iam ---
+
+resource "aws_iam_policy" "app" {}
+
+resource "aws_iam_role" "app" {}
+
+# --- aws_s3 ---
+
+resource "aws_s3_bucket" "logs" {}
+
+# --- Outputs ---
+
+output "arn" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SectionHeaders: SectionHeadersPrefix},
+		},
+		{
+			name: "stale and attached headers are rebuilt, other comments kept",
+			inputHCL: `# --- Resources ---
+# The log bucket
+resource "aws_s3_bucket" "logs" {}
+
+# --- Variables ---
+
+# --- Networking ---
+variable "region" {}
+`,
+			wantHCL: `# --- Variables ---
+
+# --- Networking ---
+variable "region" {}
+
+# --- Resources ---
+
+# The log bucket
+resource "aws_s3_bucket" "logs" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SectionHeaders: SectionHeadersBlockType},
+		},
+		{
+			name: "headers removed when disabled",
+			inputHCL: `# --- Variables ---
+
+variable "region" {}
+`,
+			wantHCL: `variable "region" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true},
+		},
+		{
+			name: "variable group headers take precedence",
+			inputHCL: `variable "zone" {
+  default = "a"
+}
+variable "name" {}
+output "x" {}
+`,
+			wantHCL: `# --- Required variables ---
+
+variable "name" {}
+
+# --- Optional variables ---
+
+variable "zone" {
+  default = "a"
+}
+
+# --- Outputs ---
+
+output "x" {}
+`,
+			sortOptions: SortOptions{
+				SortBlocks:           true,
+				VariableOrder:        VariableOrderRequiredFirst,
+				VariableGroupHeaders: true,
+				SectionHeaders:       SectionHeadersBlockType,
+			},
+		},
+		{
+			name: "hand-written header kept without header generation",
+			inputHCL: `variable "b" {}
+
+# --- Modules ---
+module "a" {}
+`,
+			wantHCL: `variable "b" {}
+
+# --- Modules ---
+module "a" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hclFile, diags := parser.ParseHCL([]byte(tc.inputHCL), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+
+			sortedFile, err := Sort(hclFile, tc.sortOptions)
+			if err != nil {
+				t.Fatalf("Sort() unexpected error = %v", err)
+			}
+			got := sortedFile.Bytes()
+			if string(got) != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+
+			// Headers must not pile up or drift on later runs.
+			resortedFile, diags := parser.ParseHCL(got, "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse sorted HCL: %v", diags)
+			}
+			resorted, err := Sort(resortedFile, tc.sortOptions)
+			if err != nil {
+				t.Fatalf("Sort() on sorted output unexpected error = %v", err)
+			}
+			if string(resorted.Bytes()) != tc.wantHCL {
+				t.Errorf("Sort() is not idempotent\nGot:\n%s\nWant:\n%s", resorted.Bytes(), tc.wantHCL)
+			}
+		})
+	}
+}
+
+func TestTypePrefix(t *testing.T) {
+	tests := map[string]string{
+		"aws_iam_role":            "aws_iam",
+		"google_compute_instance": "google_compute",
+		"random_id":               "random_id",
+		"null":                    "null",
+	}
+	for typeName, want := range tests {
+		if got := typePrefix(typeName); got != want {
+			t.Errorf("typePrefix(%q) = %q, want %q", typeName, got, want)
+		}
+	}
+}
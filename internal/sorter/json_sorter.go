@@ -0,0 +1,339 @@
+package sorter
+
+import (
+	"encoding/json"
+	"math/big"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+// jsonCommentKey is the property name Terraform JSON syntax reserves for comments.
+const jsonCommentKey = "//"
+
+// jsonLabelCounts holds the number of labels of the block types that take more
+// than one, such as resource "aws_s3_bucket" "logs" or Packer's source
+// "amazon-ebs" "ubuntu". In JSON syntax every label is a level of nested
+// objects; other block types are taken to have one.
+var jsonLabelCounts = map[string]int{
+	"resource": 2,
+	"data":     2,
+	"source":   2,
+}
+
+// SortJSON sorts a configuration file in Terraform JSON syntax (.tf.json) in
+// place, applying the same rules as Sort does to native syntax:
+//   - top-level keys follow the block order (terraform, provider, variable, ...)
+//   - the blocks of the types the profile sorts by label, such as resource and
+//     data, are sorted by every label
+//   - variables are ordered according to options.VariableOrder
+//   - locals are ordered according to options.SortLocals
+//   - arrays of strings, numbers and booleans are sorted, within the list
+//     scopes of the profile
+//
+// It fails if locals cannot be ordered by dependency because they form a
+// cycle.
+func SortJSON(file *parser.JSONFile, options SortOptions) error {
+	if file == nil || file.Root == nil {
+		return nil
+	}
+	root := file.Root
+	profile := options.profile()
+
+	if options.SortBlocks {
+		sort.SliceStable(root.Members, func(i, j int) bool {
+			return jsonBlockSortKey(root.Members[i].Key, profile) < jsonBlockSortKey(root.Members[j].Key, profile)
+		})
+	}
+
+	for _, member := range root.Members {
+		if options.SortTypeName && profile.LabelSortTypes[member.Key] {
+			labelCount, ok := jsonLabelCounts[member.Key]
+			if !ok {
+				labelCount = 1
+			}
+			sortJSONLabels(member.Value, labelCount)
+		}
+
+		switch member.Key {
+		case "variable":
+			if options.VariableOrder != VariableOrderRequiredFirst {
+				continue
+			}
+			for _, variables := range jsonObjects(member.Value) {
+				sortJSONMembers(variables, func(a, b parser.JSONMember) bool {
+					requiredA := isRequiredJSONVariable(a.Value)
+					requiredB := isRequiredJSONVariable(b.Value)
+					if requiredA != requiredB {
+						return requiredA
+					}
+					return jsonKeyLess(a, b)
+				})
+			}
+		case "locals":
+			for _, locals := range jsonObjects(member.Value) {
+				switch options.SortLocals {
+				case LocalsOrderAlphabetical:
+					sortJSONMembers(locals, jsonKeyLess)
+				case LocalsOrderDependency:
+					if err := orderJSONLocalsByDependency(locals); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	if options.SortList {
+		sortJSONArrays(root, nil, profile.ListScopes)
+	}
+	return nil
+}
+
+// SortJSONVariables sorts a variable definitions file in JSON syntax
+// (.tfvars.json) in place. Top-level keys are sorted when options.SortAttributes
+// is set, and arrays of primitive values within the list scopes of the profile
+// when options.SortList is set.
+func SortJSONVariables(file *parser.JSONFile, options SortOptions) {
+	if file == nil || file.Root == nil {
+		return
+	}
+	if options.SortAttributes {
+		sortJSONMembers(file.Root, jsonKeyLess)
+	}
+	if options.SortList {
+		sortJSONArrays(file.Root, nil, options.profile().ListScopes)
+	}
+}
+
+// jsonBlockSortKey returns the sort key of a top-level key of a JSON
+// configuration. Comments stay at the top.
+func jsonBlockSortKey(key string, profile *Profile) int {
+	if key == jsonCommentKey {
+		return 0
+	}
+	return profile.blockRank(key)
+}
+
+// jsonObjects returns the objects held by a block type value. Terraform JSON
+// syntax allows either a single object or an array of objects.
+func jsonObjects(value any) []*parser.JSONObject {
+	switch v := value.(type) {
+	case *parser.JSONObject:
+		return []*parser.JSONObject{v}
+	case []any:
+		var objects []*parser.JSONObject
+		for _, element := range v {
+			if object, ok := element.(*parser.JSONObject); ok {
+				objects = append(objects, object)
+			}
+		}
+		return objects
+	}
+	return nil
+}
+
+// sortJSONLabels sorts the blocks of a type held by value by their labels,
+// which are the keys of the first labelCount levels of nested objects.
+func sortJSONLabels(value any, labelCount int) {
+	if labelCount == 0 {
+		return
+	}
+	for _, object := range jsonObjects(value) {
+		sortJSONMembers(object, jsonKeyLess)
+		for _, member := range object.Members {
+			sortJSONLabels(member.Value, labelCount-1)
+		}
+	}
+}
+
+// orderJSONLocalsByDependency orders the members of locals, a locals object in
+// JSON syntax, so that every local comes after the locals it references, as
+// orderLocalsByDependency does for native syntax.
+func orderJSONLocalsByDependency(locals *parser.JSONObject) error {
+	names := make([]string, len(locals.Members))
+	references := make([][]string, len(locals.Members))
+	for i, member := range locals.Members {
+		names[i] = member.Key
+		references[i] = jsonLocalReferences(member.Value)
+	}
+	order, err := localsDependencyOrder(names, references)
+	if err != nil {
+		return err
+	}
+	members := make([]parser.JSONMember, len(order))
+	for i, j := range order {
+		members[i] = locals.Members[j]
+	}
+	locals.Members = members
+	return nil
+}
+
+// jsonLocalReferences returns the names of the locals referenced by the string
+// templates in value, such as "${local.name}-logs".
+func jsonLocalReferences(value any) []string {
+	var names []string
+	switch v := value.(type) {
+	case string:
+		expr, diags := hclsyntax.ParseTemplate([]byte(v), "", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil
+		}
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() != "local" || len(traversal) < 2 {
+				continue
+			}
+			if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+				names = append(names, attr.Name)
+			}
+		}
+	case *parser.JSONObject:
+		for _, member := range v.Members {
+			names = append(names, jsonLocalReferences(member.Value)...)
+		}
+	case []any:
+		for _, element := range v {
+			names = append(names, jsonLocalReferences(element)...)
+		}
+	}
+	return names
+}
+
+// isRequiredJSONVariable reports whether a variable declaration has no default value.
+func isRequiredJSONVariable(value any) bool {
+	for _, object := range jsonObjects(value) {
+		if _, ok := object.Get("default"); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sortJSONMembers stably sorts the members of object with less.
+func sortJSONMembers(object *parser.JSONObject, less func(a, b parser.JSONMember) bool) {
+	sort.SliceStable(object.Members, func(i, j int) bool {
+		return less(object.Members[i], object.Members[j])
+	})
+}
+
+// jsonKeyLess orders members by key.
+func jsonKeyLess(a, b parser.JSONMember) bool {
+	return a.Key < b.Key
+}
+
+// sortJSONArrays recursively sorts every array in value whose elements are all
+// strings, numbers or booleans and whose key path matches scopes, as
+// matchesJSONScope decides. keys is the key path of value. Numbers come first in
+// numeric order, followed by the other values in lexical order.
+func sortJSONArrays(value any, keys []string, scopes []string) {
+	switch v := value.(type) {
+	case *parser.JSONObject:
+		for _, member := range v.Members {
+			sortJSONArrays(member.Value, append(keys[:len(keys):len(keys)], member.Key), scopes)
+		}
+	case []any:
+		if !isPrimitiveJSONArray(v) {
+			for _, element := range v {
+				sortJSONArrays(element, keys, scopes)
+			}
+			return
+		}
+		if scopes != nil && !matchesJSONScope(scopes, keys) {
+			return
+		}
+		sort.SliceStable(v, func(i, j int) bool {
+			return lessJSONPrimitives(v[i], v[j])
+		})
+	}
+}
+
+// matchesJSONScope reports whether the key path of an array matches one of the
+// list scopes. JSON syntax mixes block labels into the key path, which a scope
+// leaves out, so a scope matches when its first and last parts match the first
+// and last keys and the parts in between match keys in between, in order.
+func matchesJSONScope(scopes []string, keys []string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		parts := strings.Split(scope, ".")
+		if len(parts) > len(keys) ||
+			!matchesPathPart(parts[0], keys[0]) ||
+			!matchesPathPart(parts[len(parts)-1], keys[len(keys)-1]) {
+			continue
+		}
+		if len(parts) == 1 {
+			if len(keys) == 1 {
+				return true
+			}
+			continue
+		}
+		middle := parts[1 : len(parts)-1]
+		for _, key := range keys[1 : len(keys)-1] {
+			if len(middle) > 0 && matchesPathPart(middle[0], key) {
+				middle = middle[1:]
+			}
+		}
+		if len(middle) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPathPart reports whether a key matches a part of a list scope.
+func matchesPathPart(pattern, key string) bool {
+	matched, _ := path.Match(pattern, key)
+	return matched
+}
+
+// isPrimitiveJSONArray reports whether every element of array is a string,
+// number or boolean.
+func isPrimitiveJSONArray(array []any) bool {
+	for _, element := range array {
+		switch element.(type) {
+		case string, json.Number, bool:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// lessJSONPrimitives compares two primitive JSON values.
+func lessJSONPrimitives(a, b any) bool {
+	numberA, isNumberA := a.(json.Number)
+	numberB, isNumberB := b.(json.Number)
+	switch {
+	case isNumberA && isNumberB:
+		valA, okA := new(big.Float).SetString(numberA.String())
+		valB, okB := new(big.Float).SetString(numberB.String())
+		if okA && okB {
+			if cmp := valA.Cmp(valB); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return numberA.String() < numberB.String()
+	case isNumberA != isNumberB:
+		return isNumberA
+	}
+	return jsonPrimitiveText(a) < jsonPrimitiveText(b)
+}
+
+// jsonPrimitiveText returns the text used to compare a string or boolean.
+func jsonPrimitiveText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
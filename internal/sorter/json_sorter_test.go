@@ -0,0 +1,253 @@
+package sorter
+
+import (
+	"testing"
+
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+func TestSortJSON(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		want        string
+		wantErr     string
+		variables   bool
+		sortOptions SortOptions
+	}{
+		{
+			name: "blocks, types, names and lists",
+			input: `{
+  "output": {"id": {"value": "${aws_s3_bucket.b.id}"}},
+  "resource": {
+    "aws_s3_bucket": {"b": {"bucket": "b"}, "a": {"bucket": "a"}},
+    "aws_iam_role": [{"r": {"name": "r", "depends_on": ["aws_s3_bucket.b", "aws_s3_bucket.a"]}}]
+  },
+  "//": "generated",
+  "variable": {"zones": {"default": [3, 1, "b", 2.5, "a"]}}
+}`,
+			want: `{
+  "//": "generated",
+  "variable": {
+    "zones": {
+      "default": [
+        1,
+        2.5,
+        3,
+        "a",
+        "b"
+      ]
+    }
+  },
+  "resource": {
+    "aws_iam_role": [
+      {
+        "r": {
+          "name": "r",
+          "depends_on": [
+            "aws_s3_bucket.a",
+            "aws_s3_bucket.b"
+          ]
+        }
+      }
+    ],
+    "aws_s3_bucket": {
+      "a": {
+        "bucket": "a"
+      },
+      "b": {
+        "bucket": "b"
+      }
+    }
+  },
+  "output": {
+    "id": {
+      "value": "${aws_s3_bucket.b.id}"
+    }
+  }
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true},
+		},
+		{
+			name:  "sorting disabled",
+			input: `{"resource": {"b": {"x": {"l": ["b", "a"]}}, "a": {}}, "variable": {}}`,
+			want: `{
+  "resource": {
+    "b": {
+      "x": {
+        "l": [
+          "b",
+          "a"
+        ]
+      }
+    },
+    "a": {}
+  },
+  "variable": {}
+}
+`,
+		},
+		{
+			name:  "required variables first and alphabetical locals",
+			input: `{"locals": {"b": 1, "a": 2}, "variable": {"opt": {"default": 1}, "req_b": {}, "req_a": {"type": "string"}}}`,
+			want: `{
+  "locals": {
+    "a": 2,
+    "b": 1
+  },
+  "variable": {
+    "req_a": {
+      "type": "string"
+    },
+    "req_b": {},
+    "opt": {
+      "default": 1
+    }
+  }
+}
+`,
+			sortOptions: SortOptions{VariableOrder: VariableOrderRequiredFirst, SortLocals: LocalsOrderAlphabetical},
+		},
+		{
+			name:  "variable definitions",
+			input: `{"zones": ["b", "a"], "region": "us-east-1", "tags": {"team": "x", "env": "prod"}}`,
+			want: `{
+  "region": "us-east-1",
+  "tags": {
+    "team": "x",
+    "env": "prod"
+  },
+  "zones": [
+    "a",
+    "b"
+  ]
+}
+`,
+			variables:   true,
+			sortOptions: SortOptions{SortAttributes: true, SortList: true},
+		},
+		{
+			name: "packer list scopes",
+			input: `{
+  "build": [{
+    "sources": ["source.amazon-ebs.b", "source.amazon-ebs.a"],
+    "provisioner": [{"shell": {"inline": ["apt-get update", "apt-get install -y nginx"]}}]
+  }]
+}`,
+			want: `{
+  "build": [
+    {
+      "sources": [
+        "source.amazon-ebs.a",
+        "source.amazon-ebs.b"
+      ],
+      "provisioner": [
+        {
+          "shell": {
+            "inline": [
+              "apt-get update",
+              "apt-get install -y nginx"
+            ]
+          }
+        }
+      ]
+    }
+  ]
+}
+`,
+			sortOptions: SortOptions{SortList: true, Profile: PackerProfile},
+		},
+		{
+			name:  "packer sources sorted by label",
+			input: `{"build": [{"sources": ["source.docker.a"]}], "source": {"docker": {"b": {}, "a": {}}, "amazon-ebs": {"web": {}}}}`,
+			want: `{
+  "source": {
+    "amazon-ebs": {
+      "web": {}
+    },
+    "docker": {
+      "a": {},
+      "b": {}
+    }
+  },
+  "build": [
+    {
+      "sources": [
+        "source.docker.a"
+      ]
+    }
+  ]
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, Profile: PackerProfile},
+		},
+		{
+			name:  "locals ordered by dependency",
+			input: `{"locals": {"bucket": "${local.prefix}-logs", "tags": {"Name": "${local.bucket}"}, "prefix": "${var.env}", "zones": ["a"]}}`,
+			want: `{
+  "locals": {
+    "prefix": "${var.env}",
+    "bucket": "${local.prefix}-logs",
+    "tags": {
+      "Name": "${local.bucket}"
+    },
+    "zones": [
+      "a"
+    ]
+  }
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderDependency},
+		},
+		{
+			name:        "locals in a dependency cycle",
+			input:       `{"locals": {"a": "${local.b}", "b": "${local.a}"}}`,
+			wantErr:     "cannot order locals by dependency: cycle between a, b",
+			sortOptions: SortOptions{SortLocals: LocalsOrderDependency},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sortJSON := SortJSON
+			if tc.variables {
+				sortJSON = func(file *parser.JSONFile, options SortOptions) error {
+					SortJSONVariables(file, options)
+					return nil
+				}
+			}
+
+			file, err := parser.ParseJSON([]byte(tc.input), "test.tf.json")
+			if err != nil {
+				t.Fatalf("Failed to parse input JSON: %v", err)
+			}
+			err = sortJSON(file, tc.sortOptions)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error = %v", err)
+			}
+			got := string(file.Bytes())
+			if got != tc.want {
+				t.Errorf("output mismatch\nGot:\n%s\nWant:\n%s", got, tc.want)
+			}
+
+			// Sorting the output again must not change it.
+			again, err := parser.ParseJSON([]byte(got), "test.tf.json")
+			if err != nil {
+				t.Fatalf("Failed to parse sorted JSON: %v", err)
+			}
+			if err := sortJSON(again, tc.sortOptions); err != nil {
+				t.Fatalf("unexpected error sorting again = %v", err)
+			}
+			if string(again.Bytes()) != got {
+				t.Errorf("not idempotent\nFirst:\n%s\nSecond:\n%s", got, again.Bytes())
+			}
+		})
+	}
+}
@@ -2,8 +2,8 @@ package sorter
 
 import (
 	"bytes"
+	"path"
 	"sort"
-	"strings"
 
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
@@ -14,6 +14,16 @@ import (
 // SortListValuesInBody recursively finds and sorts simple lists within a body.
 // This function is intended to be called from the main Sort function.
 func SortListValuesInBody(body *hclwrite.Body) {
+	sortListValuesInScope(body, "", "", nil, nil)
+}
+
+// sortListValuesInScope sorts the lists of the attributes in body whose path
+// matches one of scopes, recursing into nested blocks. The path of an attribute
+// joins the types of its enclosing blocks and its name with dots, such as
+// "run.expect_failures"; scopes are path.Match patterns. A nil scopes sorts
+// every list. If explain is set, the lists are recorded under their path with
+// the labels of the top-level block, which addressPrefix holds for body.
+func sortListValuesInScope(body *hclwrite.Body, prefix, addressPrefix string, scopes []string, explain *Explanation) {
 	if body == nil {
 		return
 	}
@@ -31,7 +41,27 @@ func SortListValuesInBody(body *hclwrite.Body) {
 		attr := attrs[name]
 		originalExprTokens := attr.Expr().BuildTokens(nil)
 
-		newExprTokens, wasModified := findAndSortListsInExpression(originalExprTokens)
+		var record listRecorder
+		if explain != nil {
+			path := addressPrefix + name
+			record = func(before, after []string, skipped string) {
+				explain.Lists = append(explain.Lists, ListChange{Path: path, Before: before, After: after, Skipped: skipped})
+			}
+		}
+
+		if scopes != nil && !matchesScope(scopes, prefix+name) {
+			// Record the lists that would be sorted if the attribute were in scope.
+			if record != nil {
+				findAndSortListsInExpression(originalExprTokens, func(before, after []string, skipped string) {
+					if skipped == "" {
+						record(before, nil, SkipOutOfScope)
+					}
+				})
+			}
+			continue
+		}
+
+		newExprTokens, wasModified := findAndSortListsInExpression(originalExprTokens, record)
 
 		if wasModified {
 			body.SetAttributeRaw(name, newExprTokens)
@@ -40,24 +70,39 @@ func SortListValuesInBody(body *hclwrite.Body) {
 
 	// Recursively process nested blocks (resource, module, etc.)
 	for _, block := range body.Blocks() {
-		SortListValuesInBody(block.Body())
+		blockPrefix := block.Type() + "."
+		if addressPrefix == "" {
+			blockPrefix = blockAddress(block) + "."
+		}
+		sortListValuesInScope(block.Body(), prefix+block.Type()+".", addressPrefix+blockPrefix, scopes, explain)
 	}
 }
 
+// matchesScope reports whether the attribute path matches one of scopes.
+func matchesScope(scopes []string, attrPath string) bool {
+	for _, scope := range scopes {
+		if matched, _ := path.Match(scope, attrPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // findAndSortListsInExpression recursively searches for and sorts list literals within HCL expression tokens.
 // Handles simple lists [1, 2, 3], toset() calls, and lists nested inside function calls like concat().
-// Returns the modified tokens and true if any lists were sorted.
-func findAndSortListsInExpression(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+// Returns the modified tokens and true if any lists were sorted. Every list is
+// passed to record.
+func findAndSortListsInExpression(tokens hclwrite.Tokens, record listRecorder) (hclwrite.Tokens, bool) {
 	// Strategy 1: Handle simple list literals like [1, 2, 3] or ["a", "b"]
 	listTokens, isListLiteral := checkSimpleListLiteral(tokens)
 	if isListLiteral {
-		return sortSingleListIfPossible(listTokens)
+		return sortSingleListIfPossible(listTokens, record)
 	}
 
 	// Strategy 2: Handle toset([...]) function calls specifically
 	listTokensInsideToset, isTosetList := checkTosetListCall(tokens)
 	if isTosetList {
-		sortedInnerListTokens, listWasSorted := sortSingleListIfPossible(listTokensInsideToset)
+		sortedInnerListTokens, listWasSorted := sortSingleListIfPossible(listTokensInsideToset, record)
 		if listWasSorted {
 			return buildTosetCallTokens(sortedInnerListTokens), true
 		}
@@ -65,18 +110,29 @@ func findAndSortListsInExpression(tokens hclwrite.Tokens) (hclwrite.Tokens, bool
 	}
 
 	// Strategy 3: Handle lists nested in other function calls like concat([...], [...])
-	return findAndSortListsInFunctionCalls(tokens)
+	return findAndSortListsInFunctionCalls(tokens, record)
 }
 
 // sortSingleListIfPossible attempts to sort a single list literal, handling various comment styles.
-// Returns the sorted tokens and true if sorting was performed.
-func sortSingleListIfPossible(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+// Returns the sorted tokens and true if sorting was performed. The elements of
+// a sorted list, or the reason a list is skipped, are passed to record.
+func sortSingleListIfPossible(tokens hclwrite.Tokens, record listRecorder) (hclwrite.Tokens, bool) {
 	if !isValidListStructure(tokens) {
+		record.record(nil, nil, SkipNotListLiteral)
 		return tokens, false
 	}
 
 	innerTokens := tokens[1 : len(tokens)-1]
-	if len(innerTokens) == 0 || checkIgnoreDirective(innerTokens) {
+	if isForExpression(innerTokens) {
+		record.record(nil, nil, SkipNotListLiteral)
+		return tokens, false
+	}
+	if len(innerTokens) == 0 {
+		record.record(nil, nil, SkipTooFewElements)
+		return tokens, false
+	}
+	if checkIgnoreDirective(innerTokens) {
+		record.record(nil, nil, SkipIgnoreDirective)
 		return tokens, false
 	}
 
@@ -91,15 +147,22 @@ func sortSingleListIfPossible(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
 
 	// Parse individual list elements and check if sorting is worthwhile
 	elements, ok := extractSimpleListElements(tokensToProcess)
-	if !ok || len(elements) <= 1 {
-		return tokens, false // Not sortable or too few elements
+	if !ok {
+		record.record(nil, nil, SkipCommentLayout)
+		return tokens, false
+	}
+	if len(elements) <= 1 {
+		record.record(elementTexts(elements), nil, SkipTooFewElements)
+		return tokens, false
 	}
 
 	// Perform the actual sorting
+	before := elementTexts(elements)
 	sortedElements, hasChanged := sortListElements(elements)
 	if !hasChanged {
 		return tokens, false // No changes needed
 	}
+	record.record(before, elementTexts(sortedElements), "")
 
 	// Reconstruct the list using the appropriate formatting strategy
 	if len(bracketComments) > 0 {
@@ -123,6 +186,19 @@ func isValidListStructure(tokens hclwrite.Tokens) bool {
 		tokens[len(tokens)-1].Type == hclsyntax.TokenCBrack
 }
 
+// isForExpression reports whether the inner tokens of a bracket pair are a for
+// expression, such as [for s in var.subnets : s.id], rather than list elements.
+func isForExpression(innerTokens hclwrite.Tokens) bool {
+	for _, tok := range innerTokens {
+		switch tok.Type {
+		case hclsyntax.TokenNewline, hclsyntax.TokenComment, hclsyntax.TokenTabs:
+			continue
+		}
+		return tok.Type == hclsyntax.TokenIdent && string(tok.Bytes) == "for"
+	}
+	return false
+}
+
 // sortListElements sorts the given list elements and returns the sorted list and whether any change occurred
 func sortListElements(elements []listElement) ([]listElement, bool) {
 	originalOrder := make([]listElement, len(elements))
@@ -437,13 +513,7 @@ func checkIgnoreDirective(innerListTokens hclwrite.Tokens) bool {
 	// Allows for optional whitespace/newline before the comment.
 	for _, tok := range innerListTokens {
 		if tok.Type == hclsyntax.TokenComment {
-			commentContent := strings.TrimSpace(string(tok.Bytes))
-			if strings.HasPrefix(commentContent, "//") {
-				commentContent = strings.TrimSpace(strings.TrimPrefix(commentContent, "//"))
-			} else if strings.HasPrefix(commentContent, "#") {
-				commentContent = strings.TrimSpace(strings.TrimPrefix(commentContent, "#"))
-			}
-			if commentContent == "tfsort:ignore" {
+			if commentText(tok) == ignoreDirective {
 				// Ignore directive found before any actual list element.
 				return true
 			}
@@ -567,6 +637,9 @@ func extractPrimaryTokenBytes(elementTokens hclwrite.Tokens) (key []byte, val ct
 // extractSimpleListElements parses the inner tokens of a simple list (excluding outer brackets)
 // and extracts each element as a listElement, preserving leading comments and trailing comments.
 // It supports both multi-line lists (grouped by line) and inline lists (grouped by commas).
+// It returns false if a comment cannot be kept with an element: a comment after
+// the last element, or a block comment in front of an element on the line of
+// the previous one.
 func extractSimpleListElements(innerTokens hclwrite.Tokens) ([]listElement, bool) {
 	var elements []listElement
 	var current hclwrite.Tokens
@@ -593,6 +666,11 @@ func extractSimpleListElements(innerTokens hclwrite.Tokens) ([]listElement, bool
 				}
 				break
 			}
+			if last := current[len(current)-1]; last.Type == hclsyntax.TokenComment &&
+				!bytes.HasSuffix(last.Bytes, []byte("\n")) && i+1 < len(innerTokens) &&
+				innerTokens[i+1].Type != hclsyntax.TokenNewline {
+				return nil, false
+			}
 			// Parse this element slice
 			elem, isEmpty, ok := parseSingleElement(current)
 			if !ok {
@@ -605,7 +683,7 @@ func extractSimpleListElements(innerTokens hclwrite.Tokens) ([]listElement, bool
 		} else if i == len(innerTokens)-1 {
 			// Last element without trailing comma
 			elem, isEmpty, ok := parseSingleElement(current)
-			if !ok {
+			if !ok || (isEmpty && elem != nil && containsComment(elem.LeadingComments)) {
 				return nil, false
 			}
 			if !isEmpty {
@@ -668,7 +746,8 @@ func buildTosetCallTokens(sortedListTokens hclwrite.Tokens) hclwrite.Tokens {
 
 // findAndSortListsInFunctionCalls recursively searches for list literals inside function call arguments
 // like concat([...], [...]) and sorts any that are found. Returns modified tokens and whether any changes were made.
-func findAndSortListsInFunctionCalls(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+// Every list, but not index brackets, is passed to record.
+func findAndSortListsInFunctionCalls(tokens hclwrite.Tokens, record listRecorder) (hclwrite.Tokens, bool) {
 	anySorted := false
 	result := make(hclwrite.Tokens, len(tokens))
 	copy(result, tokens)
@@ -695,7 +774,15 @@ func findAndSortListsInFunctionCalls(tokens hclwrite.Tokens) (hclwrite.Tokens, b
 			// If we found a complete bracket pair, try to sort it as a list
 			if level == 0 {
 				listTokens := result[i:j]
-				sortedListTokens, wasSorted := sortSingleListIfPossible(listTokens)
+				listRecord := record
+				if isIndexBracket(result, i) {
+					listRecord = nil
+				} else if inOrderSensitiveCall(result, i) {
+					record.record(nil, nil, SkipOrderSensitive)
+					i = j - 1
+					continue
+				}
+				sortedListTokens, wasSorted := sortSingleListIfPossible(listTokens, listRecord)
 				if wasSorted {
 					// Replace the tokens in result
 					newResult := make(hclwrite.Tokens, 0, len(result)-len(listTokens)+len(sortedListTokens))
@@ -716,6 +803,45 @@ func findAndSortListsInFunctionCalls(tokens hclwrite.Tokens) (hclwrite.Tokens, b
 	return result, anySorted
 }
 
+// orderSensitiveFunctions are the functions whose result depends on the order
+// of the elements of their list arguments, such as element, which picks an
+// element by its index, or zipmap, which pairs the elements of two lists.
+var orderSensitiveFunctions = map[string]bool{
+	"chunklist":  true,
+	"element":    true,
+	"formatlist": true,
+	"index":      true,
+	"matchkeys":  true,
+	"reverse":    true,
+	"slice":      true,
+	"zipmap":     true,
+}
+
+// inOrderSensitiveCall reports whether the token at i is part of an argument
+// of an order-sensitive function call, at any depth: the list of
+// zipmap(keys, concat(["a"], ["b"])) is as order-sensitive as keys.
+func inOrderSensitiveCall(tokens hclwrite.Tokens, i int) bool {
+	level := 0
+	for k := i - 1; k >= 0; k-- {
+		switch tokens[k].Type {
+		case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace:
+			level++
+		case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace:
+			if level > 0 {
+				level--
+				continue
+			}
+			// An unclosed opener encloses the token; check the call it opens
+			// and go on with the calls around it.
+			if tokens[k].Type == hclsyntax.TokenOParen && k > 0 && tokens[k-1].Type == hclsyntax.TokenIdent &&
+				orderSensitiveFunctions[string(tokens[k-1].Bytes)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // separateBracketCommentsFromElements distinguishes between bracket-level comments like "[ #comment"
 // and element-level comments that appear before individual list items.
 // Bracket-level comments appear immediately after the opening bracket without a newline.
@@ -139,6 +139,68 @@ resource "test" "example" {
 	}
 }
 
+// TestListsLeftUnsortedToKeepTheirMeaning covers the lists that sorting used
+// to change the meaning of, or lose the comments of, and that are now left
+// alone.
+func TestListsLeftUnsortedToKeepTheirMeaning(t *testing.T) {
+	tests := []struct {
+		name        string
+		inputHCL    string
+		description string
+	}{
+		{name: "chunklist", inputHCL: `x = chunklist(["b", "a"], 1)`, description: "chunklist splits a list by position"},
+		{name: "element", inputHCL: `x = element(["b", "a"], 0)`, description: "element picks an element by index"},
+		{name: "formatlist", inputHCL: `x = formatlist("%s-%s", ["b", "a"], ["d", "c"])`, description: "formatlist pairs elements by position"},
+		{name: "index", inputHCL: `x = index(["b", "a"], "a")`, description: "index returns a position"},
+		{name: "matchkeys", inputHCL: `x = matchkeys(["b", "a"], ["d", "c"], ["c"])`, description: "matchkeys pairs elements by position"},
+		{name: "reverse", inputHCL: `x = reverse(["b", "a"])`, description: "reverse depends on the original order"},
+		{name: "slice", inputHCL: `x = slice(["b", "a", "c"], 0, 1)`, description: "slice picks elements by position"},
+		{name: "zipmap", inputHCL: `x = zipmap(["b", "a"], ["d", "c"])`, description: "zipmap pairs elements by position"},
+		{
+			name:        "nested in an order-sensitive argument",
+			inputHCL:    `x = zipmap(["b", "a"], concat(["d"], ["c", "e"]))`,
+			description: "lists nested in an argument of zipmap feed its pairing",
+		},
+		{
+			name:        "for expression with two iterators",
+			inputHCL:    `x = [for k, v in var.m : v]`,
+			description: "brackets holding a for expression are not a list",
+		},
+		{
+			name:        "comment after the last element",
+			inputHCL:    "x = [\n  \"b\",\n  \"a\",\n  # about a\n]",
+			description: "the comment belongs to no element and would be lost",
+		},
+		{
+			name:        "block comment on the line of the previous element",
+			inputHCL:    `x = ["b", /* about a */ "a"]`,
+			description: "the comment would move with the wrong element",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hclFile, diags := parser.ParseHCL([]byte(tt.inputHCL+"\n"), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+
+			sortedFile, err := Sort(hclFile, SortOptions{SortBlocks: true, SortTypeName: true, SortList: true})
+			if err != nil {
+				t.Errorf("Sort() error = %v", err)
+				return
+			}
+
+			got := cleanHCL(sortedFile.Bytes())
+			want := cleanHCL([]byte(tt.inputHCL))
+
+			if got != want {
+				t.Errorf("Sort() changed a list that must keep its order: %s\nGot:\n%s\nWant:\n%s", tt.description, got, want)
+			}
+		})
+	}
+}
+
 func TestComplexCommentScenarios(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -218,6 +218,67 @@ resource "aws_security_group" "allow_common_ports" {
     Name = "common-ports-sg"
   }
 }
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true},
+		},
+		// --- Test: Lists whose comments sorting cannot keep ---
+		{
+			name: "comments that cannot move with their elements keep the list",
+			inputHCL: `
+resource "test" "example" {
+  trailing = [
+    "c",
+    "b",
+    # about a
+  ]
+  inline = ["b", /* about a */ "a"]
+}
+`,
+			wantHCL: `
+resource "test" "example" {
+  trailing = [
+    "c",
+    "b",
+    # about a
+  ]
+  inline = ["b", /* about a */ "a"]
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true},
+		},
+		// --- Test: Lists passed to order-sensitive functions ---
+		{
+			name: "order-sensitive function arguments are not sorted at any depth",
+			inputHCL: `
+resource "test" "example" {
+  first = element(["b", "a"], 0)
+  tags  = zipmap(["b", "a"], concat(["y"], ["x", "w"]))
+  ids   = concat(["d", "c"], reverse(["f", "e"]))
+}
+`,
+			wantHCL: `
+resource "test" "example" {
+  first = element(["b", "a"], 0)
+  tags  = zipmap(["b", "a"], concat(["y"], ["x", "w"]))
+  ids   = concat(["c", "d"], reverse(["f", "e"]))
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true},
+		},
+		// --- Test: For expressions ---
+		{
+			name: "for expressions are not sorted as lists",
+			inputHCL: `
+resource "test" "example" {
+  ids   = [for s in var.subnets : s.id]
+  names = [for k, a in var.m : a]
+}
+`,
+			wantHCL: `
+resource "test" "example" {
+  ids   = [for s in var.subnets : s.id]
+  names = [for k, a in var.m : a]
+}
 `,
 			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true},
 		},
@@ -0,0 +1,276 @@
+package sorter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// LocalsOrder defines how the keys of `locals` blocks are ordered.
+type LocalsOrder string
+
+const (
+	// LocalsOrderNone keeps locals in their original order.
+	LocalsOrderNone LocalsOrder = ""
+	// LocalsOrderAlphabetical sorts locals by name.
+	LocalsOrderAlphabetical LocalsOrder = "alphabetical"
+	// LocalsOrderDependency places every local after the locals it references.
+	LocalsOrderDependency LocalsOrder = "dependency"
+)
+
+// ParseLocalsOrder converts a command-line value into a LocalsOrder.
+func ParseLocalsOrder(value string) (LocalsOrder, error) {
+	switch order := LocalsOrder(value); order {
+	case LocalsOrderNone, LocalsOrderAlphabetical, LocalsOrderDependency:
+		return order, nil
+	}
+	return LocalsOrderNone, fmt.Errorf("invalid locals order %q (want %q or %q)", value, LocalsOrderAlphabetical, LocalsOrderDependency)
+}
+
+// processLocalsBlocks merges and sorts the top-level locals blocks according to
+// options and returns the resulting list of blocks. Blocks that are not changed
+// are returned as-is.
+func processLocalsBlocks(blocks []*hclwrite.Block, options SortOptions) ([]*hclwrite.Block, error) {
+	var err error
+	if options.MergeLocals {
+		blocks, err = mergeLocalsBlocks(blocks)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.SortLocals == LocalsOrderNone {
+		return blocks, nil
+	}
+
+	result := make([]*hclwrite.Block, len(blocks))
+	for i, block := range blocks {
+		result[i] = block
+		if block.Type() != "locals" {
+			continue
+		}
+		sorted, err := sortLocalsBlock(block, options.SortLocals)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = sorted
+	}
+	return result, nil
+}
+
+// mergeLocalsBlocks combines all locals blocks into the first one. Comments above
+// the merged blocks are kept above their first local. It fails if the same local
+// is defined in more than one block.
+func mergeLocalsBlocks(blocks []*hclwrite.Block) ([]*hclwrite.Block, error) {
+	var localsBlocks []*hclwrite.Block
+	for _, block := range blocks {
+		if block.Type() == "locals" {
+			localsBlocks = append(localsBlocks, block)
+		}
+	}
+	if len(localsBlocks) < 2 {
+		return blocks, nil
+	}
+
+	merged := splitBodyItems(localsBlocks[0].Body())
+	if len(merged.Preamble) == 0 {
+		merged.Preamble = hclwrite.Tokens{newlineToken()}
+	}
+	seen := make(map[string]bool)
+	for _, item := range merged.Items {
+		seen[item.Name] = true
+	}
+
+	for _, block := range localsBlocks[1:] {
+		layout := splitBodyItems(block.Body())
+		for i, item := range layout.Items {
+			if seen[item.Name] {
+				return nil, fmt.Errorf("cannot merge locals blocks: local value %q is defined more than once", item.Name)
+			}
+			seen[item.Name] = true
+
+			if i == 0 {
+				// Separate the merged block with a blank line and keep its comments.
+				leading := hclwrite.Tokens{newlineToken()}
+				leading = append(leading, leadComments(block)...)
+				item.Tokens = append(leading, removeLeadingNewlines(item.Tokens)...)
+			}
+			merged.Items = append(merged.Items, item)
+		}
+		merged.Trailing = append(merged.Trailing, layout.Trailing...)
+	}
+
+	mergedBlock, err := rebuildBlock(localsBlocks[0], merged.BuildTokens())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*hclwrite.Block, 0, len(blocks)-len(localsBlocks)+1)
+	for _, block := range blocks {
+		switch {
+		case block == localsBlocks[0]:
+			result = append(result, mergedBlock)
+		case block.Type() == "locals":
+			// Merged into the first locals block
+		default:
+			result = append(result, block)
+		}
+	}
+	return result, nil
+}
+
+// sortLocalsBlock returns the locals block with its keys ordered according to order.
+// The original block is returned if the order does not change.
+func sortLocalsBlock(block *hclwrite.Block, order LocalsOrder) (*hclwrite.Block, error) {
+	layout := splitBodyItems(block.Body())
+	if len(layout.Items) < 2 {
+		return block, nil
+	}
+
+	var sortedItems []bodyItem
+	switch order {
+	case LocalsOrderAlphabetical:
+		sortedItems = make([]bodyItem, len(layout.Items))
+		copy(sortedItems, layout.Items)
+		sort.SliceStable(sortedItems, func(i, j int) bool {
+			return sortedItems[i].Name < sortedItems[j].Name
+		})
+	case LocalsOrderDependency:
+		var err error
+		sortedItems, err = orderLocalsByDependency(layout.Items)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return block, nil
+	}
+
+	changed := false
+	for i := range sortedItems {
+		if sortedItems[i].Name != layout.Items[i].Name {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return block, nil
+	}
+
+	layout.Items = withoutBlankLines(sortedItems)
+	return rebuildBlock(block, layout.BuildTokens())
+}
+
+// orderLocalsByDependency performs a topological sort of the locals so that every
+// local comes after the locals it references. Locals that do not depend on each
+// other keep their original relative order. Nested blocks, which are not locals,
+// keep their positions.
+func orderLocalsByDependency(items []bodyItem) ([]bodyItem, error) {
+	var locals []bodyItem
+	var names []string
+	var references [][]string
+	for _, item := range items {
+		if item.Attribute != nil {
+			locals = append(locals, item)
+			names = append(names, item.Name)
+			references = append(references, localReferences(item.Attribute.Expr().BuildTokens(nil)))
+		}
+	}
+
+	order, err := localsDependencyOrder(names, references)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]bodyItem, len(items))
+	for i, item := range items {
+		if item.Attribute == nil {
+			result[i] = item
+			continue
+		}
+		result[i], order = locals[order[0]], order[1:]
+	}
+	return result, nil
+}
+
+// localsDependencyOrder returns the indexes of the locals, given by name and
+// the names each one references, in an order where every local comes after
+// the locals it references. Locals that do not depend on each other keep their
+// original relative order. References to undefined locals are ignored.
+func localsDependencyOrder(names []string, references [][]string) ([]int, error) {
+	defined := make(map[string]bool, len(names))
+	for _, name := range names {
+		defined[name] = true
+	}
+
+	deps := make([]map[string]bool, len(names))
+	for i, name := range names {
+		deps[i] = make(map[string]bool)
+		for _, ref := range references[i] {
+			if defined[ref] && ref != name {
+				deps[i][ref] = true
+			}
+		}
+	}
+
+	order := make([]int, 0, len(names))
+	emitted := make(map[string]bool, len(names))
+	done := make([]bool, len(names))
+	for len(order) < len(names) {
+		progress := false
+		for i, name := range names {
+			if done[i] || !allEmitted(deps[i], emitted) {
+				continue
+			}
+			order = append(order, i)
+			emitted[name] = true
+			done[i] = true
+			progress = true
+			break // Restart from the top to keep the original order stable
+		}
+		if !progress {
+			var cycle []string
+			for i, name := range names {
+				if !done[i] {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, fmt.Errorf("cannot order locals by dependency: cycle between %s", strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
+
+// allEmitted reports whether every name in deps has been emitted.
+func allEmitted(deps map[string]bool, emitted map[string]bool) bool {
+	for name := range deps {
+		if !emitted[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// localReferences returns the names of the locals referenced as `local.<name>` in the tokens.
+func localReferences(tokens hclwrite.Tokens) []string {
+	var names []string
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "local" &&
+			tokens[i+1].Type == hclsyntax.TokenDot &&
+			tokens[i+2].Type == hclsyntax.TokenIdent {
+			// Skip attribute access such as `foo.local.bar`.
+			if i > 0 && tokens[i-1].Type == hclsyntax.TokenDot {
+				continue
+			}
+			names = append(names, string(tokens[i+2].Bytes))
+		}
+	}
+	return names
+}
+
+// newlineToken returns a new newline token.
+func newlineToken() *hclwrite.Token {
+	return &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}
+}
@@ -0,0 +1,203 @@
+package sorter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+func TestLocalsSort(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+		wantErr     string
+	}{
+		{
+			name: "alphabetical with comments",
+			inputHCL: `locals {
+  # the zone
+  zone = "a"
+
+  name = "web" # display name
+  count = 2
+}
+`,
+			wantHCL: `locals {
+  count = 2
+  name  = "web" # display name
+  # the zone
+  zone = "a"
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderAlphabetical},
+		},
+		{
+			name: "alphabetical keeps brace comment and trailing comment",
+			inputHCL: `locals { # shared values
+  b = 1
+  a = 2
+  # end
+}
+`,
+			wantHCL: `locals { # shared values
+  a = 2
+  b = 1
+  # end
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderAlphabetical},
+		},
+		{
+			name: "dependency order",
+			inputHCL: `locals {
+  fqdn   = "${local.name}.${local.domain}"
+  name   = "web-${local.env}"
+  domain = "example.com"
+  env    = "prod"
+}
+`,
+			wantHCL: `locals {
+  domain = "example.com"
+  env    = "prod"
+  name   = "web-${local.env}"
+  fqdn   = "${local.name}.${local.domain}"
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderDependency},
+		},
+		{
+			name: "dependency order keeps independent locals in place",
+			inputHCL: `locals {
+  b = 1
+  a = 2
+}
+`,
+			wantHCL: `locals {
+  b = 1
+  a = 2
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderDependency},
+		},
+		{
+			name: "dependency cycle",
+			inputHCL: `locals {
+  a = local.b
+  b = local.a
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderDependency},
+			wantErr:     "cycle between a, b",
+		},
+		{
+			name: "dependency keeps nested blocks in place",
+			inputHCL: `locals {
+  b = local.a
+  nested {
+    x = 1
+  }
+  a = 1
+}
+`,
+			wantHCL: `locals {
+  a = 1
+  nested {
+    x = 1
+  }
+  b = local.a
+}
+`,
+			sortOptions: SortOptions{SortLocals: LocalsOrderDependency},
+		},
+		{
+			name: "merge locals blocks",
+			inputHCL: `# first
+locals {
+  b = 1
+}
+
+resource "x" "y" {}
+
+# second
+locals {
+  # about a
+  a = 2
+}
+`,
+			wantHCL: `# first
+locals {
+  b = 1
+
+  # second
+  # about a
+  a = 2
+}
+
+resource "x" "y" {}
+`,
+			sortOptions: SortOptions{MergeLocals: true},
+		},
+		{
+			name: "merge and sort",
+			inputHCL: `locals { b = 1 }
+locals { a = 2 }
+`,
+			wantHCL: `locals {
+  a = 2
+  b = 1
+}
+`,
+			sortOptions: SortOptions{MergeLocals: true, SortLocals: LocalsOrderAlphabetical},
+		},
+		{
+			name: "merge with duplicate key",
+			inputHCL: `locals {
+  a = 1
+}
+locals {
+  a = 2
+}
+`,
+			sortOptions: SortOptions{MergeLocals: true},
+			wantErr:     `local value "a" is defined more than once`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hclFile, diags := parser.ParseHCL([]byte(tc.inputHCL), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+
+			sortedFile, err := Sort(hclFile, tc.sortOptions)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Sort() error = %v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sort() unexpected error = %v", err)
+			}
+
+			if got := string(sortedFile.Bytes()); got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+		})
+	}
+}
+
+func TestParseLocalsOrder(t *testing.T) {
+	for _, value := range []string{"", "alphabetical", "dependency"} {
+		if _, err := ParseLocalsOrder(value); err != nil {
+			t.Errorf("ParseLocalsOrder(%q) unexpected error = %v", value, err)
+		}
+	}
+	if _, err := ParseLocalsOrder("random"); err == nil {
+		t.Error("ParseLocalsOrder(\"random\") error = nil, want error")
+	}
+}
@@ -0,0 +1,179 @@
+package sorter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// originDirective starts the comments that record which file a merged block
+// came from, such as "# tfsort:source main.tf".
+const originDirective = "tfsort:source"
+
+// MergeModule combines the files of a module, given by name, into one file
+// sorted with options. With originComments, a comment naming the source file
+// is placed above the first block of every run of blocks from the same file.
+// Comments after the last block of a file are kept, in file name order, at
+// the end of the merged file. Blocks defined in more than one place, such as
+// two variables of the same name, are an error.
+func MergeModule(files map[string][]byte, originComments bool, options SortOptions) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var items []bodyItem
+	var trailing hclwrite.Tokens
+	definedIn := make(map[string]string)
+	var duplicates []string
+	for _, name := range names {
+		file, diags := hclwrite.ParseConfig(files[name], name, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %v", name, diags)
+		}
+
+		// Blocks of a previously merged file keep the origin recorded for them.
+		origin := name
+		layout := splitFileItems(file.Body())
+		if containsComment(layout.Trailing) {
+			if len(trailing) > 0 {
+				trailing = append(trailing, newlineToken())
+			}
+			trailing = append(trailing, removeLeadingNewlines(layout.Trailing)...)
+		}
+		for _, item := range layout.Items {
+			if item.Block != nil {
+				for _, address := range mergeAddresses(item.Block) {
+					if first, ok := definedIn[address]; ok {
+						duplicates = append(duplicates, fmt.Sprintf("%s is defined in %s and %s", address, first, name))
+						continue
+					}
+					definedIn[address] = name
+				}
+			}
+			// Without origin comments, comments that look like them are the
+			// user's and are left alone.
+			if originComments {
+				var recorded string
+				item.Tokens, recorded = withoutOriginComments(item.Tokens)
+				if recorded != "" {
+					origin = recorded
+				}
+				if item.Block != nil {
+					item.Tokens = append(hclwrite.Tokens{originCommentToken(origin)}, removeLeadingNewlines(item.Tokens)...)
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	if len(duplicates) > 0 {
+		return nil, fmt.Errorf("cannot merge: %s", strings.Join(duplicates, "; "))
+	}
+
+	merged, diags := hclwrite.ParseConfig(bodyLayout{Items: withoutBlankLines(items)}.BuildTokens().Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to rebuild merged file: %v", diags)
+	}
+	sorted, err := Sort(merged, options)
+	if err != nil {
+		return nil, err
+	}
+	if !originComments {
+		return withTrailingComments(sorted.Bytes(), trailing), nil
+	}
+	return withTrailingComments(collapseOriginComments(sorted), trailing), nil
+}
+
+// mergeAddresses returns the addresses a top-level block defines, which must be
+// unique within a module. Blocks that may be repeated, such as terraform or
+// moved blocks, define none.
+func mergeAddresses(block *hclwrite.Block) []string {
+	switch block.Type() {
+	case "resource", "data", "module", "variable", "output", "check", "ephemeral":
+		return []string{blockAddress(block)}
+	case "provider":
+		address := blockAddress(block)
+		if alias := block.Body().GetAttribute("alias"); alias != nil {
+			address += "." + strings.Trim(strings.TrimSpace(string(alias.Expr().BuildTokens(nil).Bytes())), `"`)
+		}
+		return []string{address}
+	case "locals":
+		var addresses []string
+		for name := range block.Body().Attributes() {
+			addresses = append(addresses, "local."+name)
+		}
+		sort.Strings(addresses)
+		return addresses
+	}
+	return nil
+}
+
+// originCommentToken returns the comment recording that a block came from the
+// file name.
+func originCommentToken(name string) *hclwrite.Token {
+	return &hclwrite.Token{Type: hclsyntax.TokenComment, Bytes: []byte("# " + originDirective + " " + name + "\n")}
+}
+
+// originOf returns the file name recorded by an origin comment, or an empty
+// string if tok is not an origin comment.
+func originOf(tok *hclwrite.Token) string {
+	if tok.Type != hclsyntax.TokenComment {
+		return ""
+	}
+	text := commentText(tok)
+	if !strings.HasPrefix(text, originDirective+" ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(text, originDirective))
+}
+
+// withoutOriginComments removes the origin comments of a previous merge from
+// the comments leading an item and returns the last origin they record.
+func withoutOriginComments(tokens hclwrite.Tokens) (hclwrite.Tokens, string) {
+	var result hclwrite.Tokens
+	origin := ""
+	for i, tok := range tokens {
+		if tok.Type != hclsyntax.TokenComment && tok.Type != hclsyntax.TokenNewline {
+			return append(result, tokens[i:]...), origin
+		}
+		if recorded := originOf(tok); recorded != "" {
+			origin = recorded
+			continue
+		}
+		result = append(result, tok)
+	}
+	return result, origin
+}
+
+// collapseOriginComments removes the origin comments that repeat the origin of
+// the block before them, so that only the first block of each run keeps one.
+func collapseOriginComments(file *hclwrite.File) []byte {
+	layout := splitFileItems(file.Body())
+	previous := ""
+	for i, item := range layout.Items {
+		if item.Block == nil {
+			continue
+		}
+		var tokens hclwrite.Tokens
+		for j, tok := range item.Tokens {
+			if tok.Type != hclsyntax.TokenComment && tok.Type != hclsyntax.TokenNewline {
+				tokens = append(tokens, item.Tokens[j:]...)
+				break
+			}
+			if origin := originOf(tok); origin != "" {
+				if origin == previous {
+					continue
+				}
+				previous = origin
+			}
+			tokens = append(tokens, tok)
+		}
+		layout.Items[i].Tokens = tokens
+	}
+	return layout.BuildTokens().Bytes()
+}
@@ -0,0 +1,203 @@
+package sorter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeModule(t *testing.T) {
+	files := map[string]string{
+		"main.tf": `resource "aws_s3_bucket" "logs" {}
+
+locals {
+  name = "app"
+}
+`,
+		"outputs.tf": `output "bucket" {
+  value = aws_s3_bucket.logs.id
+}
+`,
+		"variables.tf": `variable "region" {}
+
+# Name prefix
+
+variable "prefix" {}
+`,
+		"versions.tf": `terraform {}
+
+provider "aws" {}
+
+provider "aws" {
+  alias = "east"
+}
+`,
+	}
+
+	testCases := []struct {
+		name           string
+		files          map[string]string
+		originComments bool
+		want           string
+		wantErr        string
+	}{
+		{
+			name:  "merges and sorts",
+			files: files,
+			want: `terraform {}
+
+provider "aws" {}
+
+provider "aws" {
+  alias = "east"
+}
+
+variable "region" {}
+
+# Name prefix
+variable "prefix" {}
+
+locals {
+  name = "app"
+}
+
+resource "aws_s3_bucket" "logs" {}
+
+output "bucket" {
+  value = aws_s3_bucket.logs.id
+}
+`,
+		},
+		{
+			name:           "origin comments separate files",
+			files:          files,
+			originComments: true,
+			want: `# tfsort:source versions.tf
+terraform {}
+
+provider "aws" {}
+
+provider "aws" {
+  alias = "east"
+}
+
+# tfsort:source variables.tf
+variable "region" {}
+
+# Name prefix
+variable "prefix" {}
+
+# tfsort:source main.tf
+locals {
+  name = "app"
+}
+
+resource "aws_s3_bucket" "logs" {}
+
+# tfsort:source outputs.tf
+output "bucket" {
+  value = aws_s3_bucket.logs.id
+}
+`,
+		},
+		{
+			name: "origin comments of a merged file are kept",
+			files: map[string]string{
+				"main.tf": `# tfsort:source versions.tf
+terraform {}
+
+# tfsort:source variables.tf
+variable "a" {}
+
+variable "b" {}
+`,
+				"outputs.tf": "output \"a\" {\n  value = var.a\n}\n",
+			},
+			originComments: true,
+			want: `# tfsort:source versions.tf
+terraform {}
+
+# tfsort:source variables.tf
+variable "a" {}
+
+variable "b" {}
+
+# tfsort:source outputs.tf
+output "a" {
+  value = var.a
+}
+`,
+		},
+		{
+			name: "comments after the last block of a file are kept",
+			files: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"logs\" {}\n\n# TODO: add replication\n",
+				"variables.tf": "variable \"name\" {}\n# End of variables\n",
+			},
+			originComments: true,
+			want: `# tfsort:source variables.tf
+variable "name" {}
+
+# tfsort:source main.tf
+resource "aws_s3_bucket" "logs" {}
+
+# TODO: add replication
+
+# End of variables
+`,
+		},
+		{
+			name: "comments like origin comments are the user's without origin comments",
+			files: map[string]string{
+				"main.tf": "# Source: https://registry.terraform.io/modules/vpc\nmodule \"vpc\" {}\n",
+			},
+			want: "# Source: https://registry.terraform.io/modules/vpc\nmodule \"vpc\" {}\n",
+		},
+		{
+			name: "comments like origin comments are the user's with origin comments",
+			files: map[string]string{
+				"main.tf": "# Source: https://registry.terraform.io/modules/vpc\nmodule \"vpc\" {}\n",
+			},
+			originComments: true,
+			want:           "# tfsort:source main.tf\n# Source: https://registry.terraform.io/modules/vpc\nmodule \"vpc\" {}\n",
+		},
+		{
+			name: "duplicate addresses",
+			files: map[string]string{
+				"a.tf": "variable \"name\" {}\n\nlocals {\n  x = 1\n}\n\nprovider \"aws\" {}\n",
+				"b.tf": "variable \"name\" {}\n\nlocals {\n  x = 2\n}\n\nprovider \"aws\" {\n  alias = \"east\"\n}\n",
+			},
+			wantErr: "cannot merge: variable.name is defined in a.tf and b.tf; local.x is defined in a.tf and b.tf",
+		},
+	}
+
+	options := SortOptions{SortBlocks: true, SortTypeName: true, SortList: true}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := make(map[string][]byte)
+			for name, content := range tc.files {
+				input[name] = []byte(content)
+			}
+			got, err := MergeModule(input, tc.originComments, options)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("MergeModule() error = %v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MergeModule() unexpected error = %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("MergeModule() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.want)
+			}
+
+			again, err := MergeModule(map[string][]byte{"main.tf": got}, tc.originComments, options)
+			if err != nil {
+				t.Fatalf("MergeModule() of the merged file unexpected error = %v", err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("MergeModule() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+}
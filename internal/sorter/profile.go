@@ -0,0 +1,407 @@
+package sorter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// unknownBlockRank is the rank of block types that a profile does not list.
+const unknownBlockRank = 99
+
+// Profile bundles the ordering rules of an HCL dialect, such as Terraform or
+// OpenTofu, together with the files it applies to.
+type Profile struct {
+	// Name identifies the profile on the command line.
+	Name string
+	// FilePatterns are the file name globs the profile is selected for.
+	FilePatterns []string
+	// BlockOrder ranks the top-level block types. Unlisted types sort last.
+	BlockOrder map[string]int
+	// LabelSortTypes lists the block types whose blocks are sorted by their
+	// labels when type/name sorting is enabled.
+	LabelSortTypes map[string]bool
+	// MetaArguments lists, per block type, the arguments that are moved to the
+	// top of the block body in the given order.
+	MetaArguments map[string][]string
+	// SortedAttributeBlocks lists the paths of the blocks whose attributes are
+	// sorted by name. A path joins the types of a block and its parents with
+	// dots, such as "run.variables".
+	SortedAttributeBlocks []string
+	// TrailingAttributes lists the top-level attributes placed after the blocks.
+	TrailingAttributes []string
+	// SortedObjectAttributes lists the paths of the attributes whose object
+	// values have their keys sorted, such as "inputs".
+	SortedObjectAttributes []string
+	// ListScopes restricts list sorting to the attributes whose path matches
+	// one of these path.Match patterns, such as "run.expect_failures". Lists
+	// are sorted everywhere when it is nil, and nowhere when it is empty.
+	ListScopes []string
+}
+
+// TerraformProfile holds the rules for Terraform configuration files.
+var TerraformProfile = &Profile{
+	Name:           "terraform",
+	FilePatterns:   []string{"*.tf", "*.tf.json", "*.tfvars", "*.tfvars.json"},
+	BlockOrder:     blockOrder,
+	LabelSortTypes: map[string]bool{"resource": true, "data": true},
+}
+
+// OpenTofuProfile holds the rules for OpenTofu configuration files. OpenTofu
+// evaluates variables and locals referenced by the terraform block early, so
+// they come first. Provider blocks may use for_each, which requires an alias;
+// both are kept at the top of the block.
+var OpenTofuProfile = &Profile{
+	Name:         "opentofu",
+	FilePatterns: []string{"*.tofu", "*.tofu.json"},
+	BlockOrder: map[string]int{
+		"variable":  1,
+		"locals":    2,
+		"terraform": 3,
+		"provider":  4,
+		"data":      5,
+		"module":    6,
+		"resource":  7,
+		"output":    8,
+	},
+	LabelSortTypes: map[string]bool{"resource": true, "data": true},
+	MetaArguments: map[string][]string{
+		"provider": {"alias", "for_each"},
+	},
+}
+
+// TerraformTestProfile holds the rules for Terraform test files. Run blocks
+// execute in file order, so they are never reordered; the blocks that
+// configure them come first.
+var TerraformTestProfile = &Profile{
+	Name:         "tftest",
+	FilePatterns: []string{"*.tftest.hcl", "*.tofutest.hcl"},
+	BlockOrder: map[string]int{
+		"test":              1,
+		"variables":         2,
+		"provider":          3,
+		"mock_provider":     4,
+		"override_resource": 5,
+		"override_data":     6,
+		"override_module":   7,
+		"run":               8,
+	},
+	SortedAttributeBlocks: []string{"variables", "run.variables"},
+	ListScopes:            []string{"variables.*", "run.variables.*", "run.expect_failures"},
+}
+
+// TerraformMockProfile holds the rules for the mock data files of Terraform tests.
+var TerraformMockProfile = &Profile{
+	Name:         "tfmock",
+	FilePatterns: []string{"*.tfmock.hcl", "*.tofumock.hcl"},
+	BlockOrder: map[string]int{
+		"mock_resource":     1,
+		"mock_data":         2,
+		"override_resource": 3,
+		"override_data":     4,
+	},
+	LabelSortTypes: map[string]bool{"mock_resource": true, "mock_data": true},
+}
+
+// TerragruntProfile holds the rules for Terragrunt configuration files. List
+// sorting is limited to dependency paths because the order of other lists,
+// such as extra CLI arguments, is significant.
+var TerragruntProfile = &Profile{
+	Name:         "terragrunt",
+	FilePatterns: []string{"terragrunt.hcl"},
+	BlockOrder: map[string]int{
+		"include":      1,
+		"locals":       2,
+		"dependency":   3,
+		"dependencies": 4,
+		"terraform":    5,
+		"remote_state": 6,
+		"generate":     7,
+	},
+	LabelSortTypes:         map[string]bool{"dependency": true},
+	TrailingAttributes:     []string{"inputs"},
+	SortedObjectAttributes: []string{"inputs"},
+	ListScopes:             []string{"dependencies.paths"},
+}
+
+// StacksProfile holds the rules for Terraform Stacks component and deployment
+// configuration files. Providers of a stack take a second label naming the
+// configuration, as in provider "aws" "east"; they are sorted by both labels.
+var StacksProfile = &Profile{
+	Name:         "stacks",
+	FilePatterns: []string{"*.tfcomponent.hcl", "*.tfdeploy.hcl", "*.tfstack.hcl"},
+	BlockOrder: map[string]int{
+		"required_providers": 1,
+		"variable":           2,
+		"locals":             3,
+		"provider":           4,
+		"component":          5,
+		"removed":            6,
+		"output":             7,
+		"identity_token":     8,
+		"store":              9,
+		"upstream_input":     10,
+		"deployment":         11,
+		"orchestrate":        12,
+		"publish_output":     13,
+	},
+	LabelSortTypes: map[string]bool{"component": true, "deployment": true, "provider": true},
+}
+
+// PackerProfile holds the rules for Packer templates and variable files. List
+// sorting is limited to the sources of builds because the order of provisioner
+// commands is significant.
+var PackerProfile = &Profile{
+	Name:         "packer",
+	FilePatterns: []string{"*.pkr.hcl", "*.pkr.json", "*.pkrvars.hcl", "*.pkrvars.json"},
+	BlockOrder: map[string]int{
+		"packer":    1,
+		"variable":  2,
+		"variables": 3,
+		"locals":    4,
+		"local":     5,
+		"data":      6,
+		"source":    7,
+		"build":     8,
+	},
+	LabelSortTypes: map[string]bool{"source": true, "data": true},
+	ListScopes:     []string{"build.sources"},
+}
+
+// GenericProfile holds conservative rules for HCL files of any other tool.
+// Blocks and lists keep their order; only the generic steps, such as sorting
+// top-level attributes, apply. It is meant to be extended by user profiles.
+var GenericProfile = &Profile{
+	Name:       "generic",
+	BlockOrder: map[string]int{},
+	ListScopes: []string{},
+}
+
+// builtinProfiles lists the built-in profiles in the order they are matched
+// against file names.
+var builtinProfiles = []*Profile{
+	TerraformProfile,
+	OpenTofuProfile,
+	TerraformTestProfile,
+	TerraformMockProfile,
+	TerragruntProfile,
+	StacksProfile,
+	PackerProfile,
+	GenericProfile,
+}
+
+// ProfileSet holds the profiles available to a run: user-defined profiles,
+// explicit mappings of file globs to profiles, and the built-in profiles.
+type ProfileSet struct {
+	custom []*Profile
+	files  []fileMapping
+}
+
+// fileMapping selects a profile for the files matching a glob.
+type fileMapping struct {
+	Pattern string
+	Profile *Profile
+}
+
+// NewProfileSet returns a set holding only the built-in profiles.
+func NewProfileSet() *ProfileSet {
+	return &ProfileSet{}
+}
+
+// Add adds a user-defined profile. It takes precedence over a built-in profile
+// of the same name and over the file patterns of the built-in profiles.
+func (s *ProfileSet) Add(profile *Profile) error {
+	for _, existing := range s.custom {
+		if existing.Name == profile.Name {
+			return fmt.Errorf("profile %q is defined more than once", profile.Name)
+		}
+	}
+	s.custom = append(s.custom, profile)
+	return nil
+}
+
+// MapFiles selects the named profile for the files whose base name matches
+// pattern. Mappings take precedence over the file patterns of all profiles and
+// are checked in the order they were added.
+func (s *ProfileSet) MapFiles(pattern, name string) error {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+	}
+	profile, err := s.Lookup(name)
+	if err != nil {
+		return err
+	}
+	s.files = append(s.files, fileMapping{Pattern: pattern, Profile: profile})
+	return nil
+}
+
+// Lookup returns the profile with the given name.
+func (s *ProfileSet) Lookup(name string) (*Profile, error) {
+	var names []string
+	for _, profile := range s.all() {
+		if profile.Name == name {
+			return profile, nil
+		}
+		names = append(names, profile.Name)
+	}
+	return nil, fmt.Errorf("unknown dialect %q (want one of %s)", name, strings.Join(names, ", "))
+}
+
+// ForFile returns the profile selected for the file at path, or nil if the
+// file is not supported.
+func (s *ProfileSet) ForFile(path string) *Profile {
+	name := filepath.Base(path)
+	for _, mapping := range s.files {
+		if matched, _ := filepath.Match(mapping.Pattern, name); matched {
+			return mapping.Profile
+		}
+	}
+	for _, profile := range s.all() {
+		if profile.Matches(name) {
+			return profile
+		}
+	}
+	return nil
+}
+
+// all returns the user-defined profiles followed by the built-in ones.
+func (s *ProfileSet) all() []*Profile {
+	profiles := append([]*Profile{}, s.custom...)
+	return append(profiles, builtinProfiles...)
+}
+
+// Clone returns a deep copy of the profile under a new name, so that user
+// profiles can extend another profile without changing it.
+func (p *Profile) Clone(name string) *Profile {
+	clone := &Profile{
+		Name:                   name,
+		FilePatterns:           append([]string(nil), p.FilePatterns...),
+		BlockOrder:             make(map[string]int, len(p.BlockOrder)),
+		LabelSortTypes:         make(map[string]bool, len(p.LabelSortTypes)),
+		MetaArguments:          make(map[string][]string, len(p.MetaArguments)),
+		SortedAttributeBlocks:  append([]string(nil), p.SortedAttributeBlocks...),
+		TrailingAttributes:     append([]string(nil), p.TrailingAttributes...),
+		SortedObjectAttributes: append([]string(nil), p.SortedObjectAttributes...),
+	}
+	for blockType, rank := range p.BlockOrder {
+		clone.BlockOrder[blockType] = rank
+	}
+	for blockType, sorted := range p.LabelSortTypes {
+		clone.LabelSortTypes[blockType] = sorted
+	}
+	for blockType, names := range p.MetaArguments {
+		clone.MetaArguments[blockType] = append([]string(nil), names...)
+	}
+	if p.ListScopes != nil {
+		clone.ListScopes = append([]string{}, p.ListScopes...)
+	}
+	return clone
+}
+
+// sortsUnlistedLabels reports whether the profile sorts the blocks of a type
+// it does not list in its block order by label.
+func (p *Profile) sortsUnlistedLabels() bool {
+	for blockType, sorted := range p.LabelSortTypes {
+		if _, listed := p.BlockOrder[blockType]; sorted && !listed {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the file name matches one of the profile's file patterns.
+func (p *Profile) Matches(name string) bool {
+	for _, pattern := range p.FilePatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// blockRank returns the sort key of a block type.
+func (p *Profile) blockRank(blockType string) int {
+	if order, ok := p.BlockOrder[blockType]; ok {
+		return order
+	}
+	// Assign a high number to unknown block types to sort them last.
+	return unknownBlockRank
+}
+
+// profile returns the profile selected by the options, Terraform by default.
+func (o SortOptions) profile() *Profile {
+	if o.Profile != nil {
+		return o.Profile
+	}
+	return TerraformProfile
+}
+
+// hoistMetaArguments moves the meta-arguments listed by the profile to the top
+// of each block. Blocks without meta-arguments out of place are returned as-is.
+func hoistMetaArguments(blocks []*hclwrite.Block, profile *Profile) ([]*hclwrite.Block, error) {
+	if len(profile.MetaArguments) == 0 {
+		return blocks, nil
+	}
+
+	result := make([]*hclwrite.Block, len(blocks))
+	for i, block := range blocks {
+		result[i] = block
+		metaArguments := profile.MetaArguments[block.Type()]
+		if len(metaArguments) == 0 {
+			continue
+		}
+
+		layout := splitBodyItems(block.Body())
+		var hoisted, rest []bodyItem
+		for _, name := range metaArguments {
+			for _, item := range layout.Items {
+				if item.Attribute != nil && item.Name == name {
+					hoisted = append(hoisted, item)
+				}
+			}
+		}
+		for _, item := range layout.Items {
+			if item.Attribute == nil || !containsString(metaArguments, item.Name) {
+				rest = append(rest, item)
+			}
+		}
+
+		if len(hoisted) > 0 && len(rest) > 0 {
+			// Separate the meta-arguments from the other arguments with a blank line.
+			first := rest[0]
+			first.Tokens = append(hclwrite.Tokens{newlineToken()}, removeLeadingNewlines(first.Tokens)...)
+			rest[0] = first
+		}
+		items := append(withoutBlankLines(hoisted), rest...)
+		changed := false
+		for j := range items {
+			if items[j].Name != layout.Items[j].Name {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		layout.Items = items
+		rebuilt, err := rebuildBlock(block, layout.BuildTokens())
+		if err != nil {
+			return nil, err
+		}
+		result[i] = rebuilt
+	}
+	return result, nil
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
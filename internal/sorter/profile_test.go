@@ -0,0 +1,722 @@
+package sorter
+
+import (
+	"testing"
+)
+
+func TestOpenTofuProfile(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "variables and locals before terraform",
+			inputHCL: `terraform {
+  encryption {
+    key_provider "pbkdf2" "main" {
+      passphrase = var.passphrase
+    }
+  }
+}
+
+provider "aws" {}
+
+locals {
+  env = "prod"
+}
+
+variable "passphrase" {}
+`,
+			wantHCL: `variable "passphrase" {}
+
+locals {
+  env = "prod"
+}
+
+terraform {
+  encryption {
+    key_provider "pbkdf2" "main" {
+      passphrase = var.passphrase
+    }
+  }
+}
+
+provider "aws" {}
+`,
+			sortOptions: SortOptions{SortBlocks: true, Profile: OpenTofuProfile},
+		},
+		{
+			name: "provider meta-arguments first",
+			inputHCL: `provider "aws" {
+  region = each.value
+
+  # One provider per region
+  for_each = toset(var.regions)
+  alias    = "by_region"
+}
+`,
+			wantHCL: `provider "aws" {
+  alias = "by_region"
+  # One provider per region
+  for_each = toset(var.regions)
+
+  region = each.value
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, Profile: OpenTofuProfile},
+		},
+		{
+			name: "terraform profile keeps provider arguments",
+			inputHCL: `provider "aws" {
+  region = "us-east-1"
+  alias  = "east"
+}
+`,
+			wantHCL: `provider "aws" {
+  region = "us-east-1"
+  alias  = "east"
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sortString(t, tc.inputHCL, tc.sortOptions)
+			if got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+			if again := sortString(t, got, tc.sortOptions); again != got {
+				t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+}
+
+func TestTerraformTestProfiles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "run blocks keep their order",
+			inputHCL: `run "create" {
+  command = apply
+  variables {
+    name   = "b"
+    cidrs  = ["10.0.1.0/24", "10.0.0.0/24"]
+  }
+  expect_failures = [var.zone, var.name]
+  assert {
+    condition     = output.ids == ["b", "a"]
+    error_message = "wrong ids"
+  }
+}
+
+run "apply" {
+  command = apply
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+variables {
+  zones = ["b", "a"]
+  # The name
+  name  = "test"
+}
+`,
+			wantHCL: `variables {
+  # The name
+  name  = "test"
+  zones = ["a", "b"]
+}
+
+provider "aws" {
+  region = "us-east-1"
+}
+
+run "create" {
+  command = apply
+  variables {
+    cidrs = ["10.0.0.0/24", "10.0.1.0/24"]
+    name  = "b"
+  }
+  expect_failures = [var.name, var.zone]
+  assert {
+    condition     = output.ids == ["b", "a"]
+    error_message = "wrong ids"
+  }
+}
+
+run "apply" {
+  command = apply
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true, Profile: TerraformTestProfile},
+		},
+		{
+			name: "mock blocks sorted by type",
+			inputHCL: `override_resource {
+  target = aws_s3_bucket.main
+}
+
+mock_data "aws_region" {
+  defaults = {
+    name = "us-east-1"
+  }
+}
+
+mock_resource "aws_s3_bucket" {}
+
+mock_resource "aws_iam_role" {}
+`,
+			wantHCL: `mock_resource "aws_iam_role" {}
+
+mock_resource "aws_s3_bucket" {}
+
+mock_data "aws_region" {
+  defaults = {
+    name = "us-east-1"
+  }
+}
+
+override_resource {
+  target = aws_s3_bucket.main
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true, Profile: TerraformMockProfile},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sortString(t, tc.inputHCL, tc.sortOptions)
+			if got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+			if again := sortString(t, got, tc.sortOptions); again != got {
+				t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+}
+
+func TestTerragruntProfile(t *testing.T) {
+	inputHCL := `# Inputs for the module
+inputs = {
+  vpc_id = dependency.vpc.outputs.vpc_id
+  # Subnets to use
+  subnets = ["b", "a"]
+  name    = "app"
+}
+
+terraform {
+  source = "../modules/app"
+  extra_arguments "vars" {
+    commands  = ["plan", "apply"]
+    arguments = ["-var-file=b.tfvars", "-var-file=a.tfvars"]
+  }
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+
+dependency "db" {
+  config_path = "../db"
+}
+
+dependencies {
+  paths = ["../vpc", "../db"]
+}
+
+locals {
+  env = "prod"
+}
+
+include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+`
+	wantHCL := `include "root" {
+  path = find_in_parent_folders("root.hcl")
+}
+
+locals {
+  env = "prod"
+}
+
+dependency "db" {
+  config_path = "../db"
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+}
+
+dependencies {
+  paths = ["../db", "../vpc"]
+}
+
+terraform {
+  source = "../modules/app"
+  extra_arguments "vars" {
+    commands  = ["plan", "apply"]
+    arguments = ["-var-file=b.tfvars", "-var-file=a.tfvars"]
+  }
+}
+
+# Inputs for the module
+inputs = {
+  name = "app"
+  # Subnets to use
+  subnets = ["b", "a"]
+  vpc_id  = dependency.vpc.outputs.vpc_id
+}
+`
+	sortOptions := SortOptions{SortBlocks: true, SortTypeName: true, SortList: true, SortAttributes: true, Profile: TerragruntProfile}
+
+	got := sortString(t, inputHCL, sortOptions)
+	if got != wantHCL {
+		t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, wantHCL)
+	}
+	if again := sortString(t, got, sortOptions); again != got {
+		t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+	}
+}
+
+func TestTerragruntDependencyOrder(t *testing.T) {
+	inputHCL := `dependency "z" {}
+
+dependencies {}
+
+dependency "a" {}
+`
+	wantHCL := `dependency "a" {}
+
+dependency "z" {}
+
+dependencies {}
+`
+	sortOptions := SortOptions{SortBlocks: true, SortTypeName: true, Profile: TerragruntProfile}
+
+	if got := sortString(t, inputHCL, sortOptions); got != wantHCL {
+		t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, wantHCL)
+	}
+}
+
+func TestBlockTypesSharingARank(t *testing.T) {
+	testCases := []struct {
+		name     string
+		profile  *Profile
+		inputHCL string
+		wantHCL  string
+	}{
+		{
+			name:    "packer variables",
+			profile: PackerProfile,
+			inputHCL: `variable "b" {}
+
+variables {}
+
+variable "a" {}
+`,
+			wantHCL: `variable "b" {}
+
+variable "a" {}
+
+variables {}
+`,
+		},
+		{
+			name: "listed types of one rank",
+			profile: &Profile{
+				BlockOrder:     map[string]int{"job": 1, "task": 1},
+				LabelSortTypes: map[string]bool{"job": true},
+			},
+			inputHCL: `task "t" {}
+
+job "b" {}
+
+task "s" {}
+
+job "a" {}
+`,
+			wantHCL: `job "a" {}
+
+job "b" {}
+
+task "t" {}
+
+task "s" {}
+`,
+		},
+		{
+			name: "unlisted types sorted by label",
+			profile: &Profile{
+				BlockOrder:     map[string]int{"variable": 1},
+				LabelSortTypes: map[string]bool{"job": true},
+			},
+			inputHCL: `job "b" {}
+
+task "t" {}
+
+job "a" {}
+
+variable "v" {}
+`,
+			wantHCL: `variable "v" {}
+
+job "a" {}
+
+job "b" {}
+
+task "t" {}
+`,
+		},
+		{
+			name:    "unlisted types keep their order",
+			profile: &Profile{BlockOrder: map[string]int{"variable": 1}},
+			inputHCL: `task "t" {}
+
+job "b" {}
+
+task "s" {}
+
+variable "v" {}
+`,
+			wantHCL: `variable "v" {}
+
+task "t" {}
+
+job "b" {}
+
+task "s" {}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sortOptions := SortOptions{SortBlocks: true, SortTypeName: true, Profile: tc.profile}
+			if got := sortString(t, tc.inputHCL, sortOptions); got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+		})
+	}
+}
+
+func TestStacksProfile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		inputHCL string
+		wantHCL  string
+	}{
+		{
+			name: "component configuration",
+			inputHCL: `output "vpc_id" {
+  type  = string
+  value = component.vpc.vpc_id
+}
+
+component "vpc" {
+  source = "./vpc"
+}
+
+component "app" {
+  source = "./app"
+}
+
+provider "aws" "west" {
+  config {
+    region = "us-west-2"
+  }
+}
+
+provider "aws" "east" {
+  config {
+    region = "us-east-1"
+  }
+}
+
+variable "regions" {
+  type = set(string)
+}
+
+required_providers {
+  aws = {
+    source = "hashicorp/aws"
+  }
+}
+`,
+			wantHCL: `required_providers {
+  aws = {
+    source = "hashicorp/aws"
+  }
+}
+
+variable "regions" {
+  type = set(string)
+}
+
+provider "aws" "east" {
+  config {
+    region = "us-east-1"
+  }
+}
+
+provider "aws" "west" {
+  config {
+    region = "us-west-2"
+  }
+}
+
+component "app" {
+  source = "./app"
+}
+
+component "vpc" {
+  source = "./vpc"
+}
+
+output "vpc_id" {
+  type  = string
+  value = component.vpc.vpc_id
+}
+`,
+		},
+		{
+			name: "deployment configuration",
+			inputHCL: `orchestrate "auto_approve" "no_destroy" {
+  check {
+    condition = context.plan.changes.remove == 0
+    reason    = "Plan removes resources."
+  }
+}
+
+deployment "production" {
+  inputs = {
+    regions = ["us-east-1"]
+  }
+}
+
+deployment "development" {
+  inputs = {
+    regions = ["us-west-2", "us-east-1"]
+  }
+}
+
+identity_token "aws" {
+  audience = ["aws.workload.identity"]
+}
+`,
+			wantHCL: `identity_token "aws" {
+  audience = ["aws.workload.identity"]
+}
+
+deployment "development" {
+  inputs = {
+    regions = ["us-east-1", "us-west-2"]
+  }
+}
+
+deployment "production" {
+  inputs = {
+    regions = ["us-east-1"]
+  }
+}
+
+orchestrate "auto_approve" "no_destroy" {
+  check {
+    condition = context.plan.changes.remove == 0
+    reason    = "Plan removes resources."
+  }
+}
+`,
+		},
+	}
+
+	options := SortOptions{SortBlocks: true, SortTypeName: true, SortList: true, Profile: StacksProfile}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sortString(t, tc.inputHCL, options)
+			if got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+			if again := sortString(t, got, options); again != got {
+				t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+
+	for _, name := range []string{"main.tfcomponent.hcl", "prod.tfdeploy.hcl", "legacy.tfstack.hcl"} {
+		if got := NewProfileSet().ForFile(name); got != StacksProfile {
+			t.Errorf("ForFile(%q) = %v, want the stacks profile", name, got)
+		}
+	}
+}
+
+func TestProfileSet(t *testing.T) {
+	profiles := NewProfileSet()
+	nomad := GenericProfile.Clone("nomad")
+	nomad.FilePatterns = []string{"*.nomad.hcl"}
+	if err := profiles.Add(nomad); err != nil {
+		t.Fatalf("Add() unexpected error = %v", err)
+	}
+	if err := profiles.Add(GenericProfile.Clone("nomad")); err == nil {
+		t.Error("Add() of a duplicate profile error = nil, want error")
+	}
+	if err := profiles.MapFiles("root.hcl", "terragrunt"); err != nil {
+		t.Fatalf("MapFiles() unexpected error = %v", err)
+	}
+	if err := profiles.MapFiles("*.hcl", "unknown"); err == nil {
+		t.Error("MapFiles() with an unknown profile error = nil, want error")
+	}
+
+	testCases := []struct {
+		path string
+		want *Profile
+	}{
+		{path: "main.tf", want: TerraformProfile},
+		{path: "dir/prod.auto.tfvars", want: TerraformProfile},
+		{path: "main.tf.json", want: TerraformProfile},
+		{path: "main.tofu", want: OpenTofuProfile},
+		{path: "dir/main.tofu.json", want: OpenTofuProfile},
+		{path: "tests/main.tftest.hcl", want: TerraformTestProfile},
+		{path: "tests/aws.tfmock.hcl", want: TerraformMockProfile},
+		{path: "live/prod/app/terragrunt.hcl", want: TerragruntProfile},
+		{path: "live/root.hcl", want: TerragruntProfile},
+		{path: "images/ubuntu.pkr.hcl", want: PackerProfile},
+		{path: "jobs/web.nomad.hcl", want: nomad},
+		{path: "notes.txt", want: nil},
+		{path: "other.hcl", want: nil},
+	}
+	for _, tc := range testCases {
+		if got := profiles.ForFile(tc.path); got != tc.want {
+			t.Errorf("ForFile(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+
+	if got, err := profiles.Lookup("opentofu"); err != nil || got != OpenTofuProfile {
+		t.Errorf("Lookup(\"opentofu\") = %v, %v, want OpenTofuProfile", got, err)
+	}
+	if got, err := profiles.Lookup("nomad"); err != nil || got != nomad {
+		t.Errorf("Lookup(\"nomad\") = %v, %v, want the user profile", got, err)
+	}
+	if _, err := profiles.Lookup("hcl2"); err == nil {
+		t.Error("Lookup(\"hcl2\") error = nil, want error")
+	}
+}
+
+func TestPackerAndGenericProfiles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "packer template",
+			inputHCL: `build {
+  sources = ["source.amazon-ebs.ubuntu", "source.amazon-ebs.debian"]
+
+  provisioner "shell" {
+    inline = ["apt-get update", "apt-get install -y nginx"]
+  }
+}
+
+source "amazon-ebs" "ubuntu" {
+  ami_name = "ubuntu"
+}
+
+source "amazon-ebs" "debian" {
+  ami_name = "debian"
+}
+
+variable "region" {
+  default = "us-east-1"
+}
+
+packer {
+  required_plugins {}
+}
+`,
+			wantHCL: `packer {
+  required_plugins {}
+}
+
+variable "region" {
+  default = "us-east-1"
+}
+
+source "amazon-ebs" "debian" {
+  ami_name = "debian"
+}
+
+source "amazon-ebs" "ubuntu" {
+  ami_name = "ubuntu"
+}
+
+build {
+  sources = ["source.amazon-ebs.debian", "source.amazon-ebs.ubuntu"]
+
+  provisioner "shell" {
+    inline = ["apt-get update", "apt-get install -y nginx"]
+  }
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true, Profile: PackerProfile},
+		},
+		{
+			name: "generic keeps blocks and lists",
+			inputHCL: `job "web" {
+  datacenters = ["dc2", "dc1"]
+}
+
+b = 1
+a = 2
+`,
+			wantHCL: `a = 2
+b = 1
+job "web" {
+  datacenters = ["dc2", "dc1"]
+}
+`,
+			sortOptions: SortOptions{SortBlocks: true, SortTypeName: true, SortList: true, SortAttributes: true, Profile: GenericProfile},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sortString(t, tc.inputHCL, tc.sortOptions)
+			if got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+			if again := sortString(t, got, tc.sortOptions); again != got {
+				t.Errorf("Sort() is not idempotent\nFirst:\n%s\nSecond:\n%s", got, again)
+			}
+		})
+	}
+}
+
+func TestProfileClone(t *testing.T) {
+	clone := TerragruntProfile.Clone("custom")
+	clone.BlockOrder["feature"] = 1
+	clone.ListScopes[0] = "inputs.*"
+	if _, ok := TerragruntProfile.BlockOrder["feature"]; ok {
+		t.Error("Clone() shares BlockOrder with the original profile")
+	}
+	if TerragruntProfile.ListScopes[0] != "dependencies.paths" {
+		t.Error("Clone() shares ListScopes with the original profile")
+	}
+	if GenericProfile.Clone("x").ListScopes == nil {
+		t.Error("Clone() lost an empty, non-nil ListScopes")
+	}
+}
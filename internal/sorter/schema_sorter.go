@@ -0,0 +1,220 @@
+package sorter
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/tjun/tfsort/internal/schema"
+)
+
+// Ranks of the sections of a block body ordered by its provider schema.
+const (
+	rankUnknown = iota - 1 // Not described by the schema; keeps its position
+	rankLeadingMeta
+	rankRequired
+	rankOptional
+	rankComputedOptional
+	rankComputed
+	rankNestedBlock
+	rankTrailingMeta
+)
+
+// Meta-arguments placed before and after the arguments defined by the provider.
+var (
+	leadingMetaArguments  = []string{"count", "for_each", "provider"}
+	trailingMetaArguments = []string{"depends_on", "lifecycle", "connection", "provisioner"}
+)
+
+// rankedItem is a body item together with its position in the schema order.
+type rankedItem struct {
+	bodyItem
+	Index   int // Position in the original body
+	Rank    int
+	SubRank int
+	Key     string
+}
+
+// orderBlocksBySchema orders the arguments of resource and data blocks the way
+// the provider schema documents them. Blocks of types unknown to the schema are
+// returned unchanged.
+func orderBlocksBySchema(blocks []*hclwrite.Block, schemas *schema.ProviderSchemas) ([]*hclwrite.Block, error) {
+	result := make([]*hclwrite.Block, len(blocks))
+	for i, block := range blocks {
+		result[i] = block
+		if block.Type() != "resource" && block.Type() != "data" {
+			continue
+		}
+		labels := block.Labels()
+		if len(labels) == 0 {
+			continue
+		}
+		blockSchema := schemas.Lookup(block.Type(), labels[0])
+		if blockSchema == nil {
+			continue
+		}
+
+		bodyTokens, changed, err := orderBodyBySchema(block.Body(), blockSchema, true)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			continue
+		}
+		rebuilt, err := rebuildBlock(block, bodyTokens)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = rebuilt
+	}
+	return result, nil
+}
+
+// orderBodyBySchema returns the tokens of body with its items ordered as follows:
+// leading meta-arguments, required, optional and computed-optional arguments
+// (each group sorted by name), nested blocks, then trailing meta-arguments.
+// Items the schema does not describe keep their position. Nested blocks are
+// ordered recursively. Returns true if anything changed.
+func orderBodyBySchema(body *hclwrite.Body, blockSchema *schema.Block, withMeta bool) (hclwrite.Tokens, bool, error) {
+	layout := splitBodyItems(body)
+	changed := false
+
+	items := make([]rankedItem, len(layout.Items))
+	for i, item := range layout.Items {
+		key := item.Name
+		if item.Block != nil && item.Name == "dynamic" && len(item.Block.Labels()) > 0 {
+			key = item.Block.Labels()[0]
+		}
+		rank, subRank := schemaRank(item, key, blockSchema, withMeta)
+
+		if item.Block != nil && item.Name != "dynamic" && rank == rankNestedBlock {
+			nestedTokens, nestedChanged, err := orderNestedBlockBySchema(item, blockSchema.BlockTypes[key])
+			if err != nil {
+				return nil, false, err
+			}
+			if nestedChanged {
+				item.Tokens = nestedTokens
+				changed = true
+			}
+		}
+		items[i] = rankedItem{bodyItem: item, Index: i, Rank: rank, SubRank: subRank, Key: key}
+	}
+
+	// Sort the known items among the positions held by known items.
+	var slots []int
+	var known []rankedItem
+	for i, item := range items {
+		if item.Rank != rankUnknown {
+			slots = append(slots, i)
+			known = append(known, item)
+		}
+	}
+	sort.SliceStable(known, func(i, j int) bool {
+		if known[i].Rank != known[j].Rank {
+			return known[i].Rank < known[j].Rank
+		}
+		if known[i].SubRank != known[j].SubRank {
+			return known[i].SubRank < known[j].SubRank
+		}
+		return known[i].Key < known[j].Key
+	})
+
+	ordered := make([]rankedItem, len(items))
+	copy(ordered, items)
+	reordered := false
+	for k, slot := range slots {
+		if known[k].Index != slot {
+			reordered = true
+		}
+		ordered[slot] = known[k]
+	}
+
+	if !reordered {
+		if !changed {
+			return nil, false, nil
+		}
+		for i := range items {
+			layout.Items[i] = items[i].bodyItem
+		}
+		return layout.BuildTokens(), true, nil
+	}
+
+	// Separate the meta-arguments, arguments and nested blocks with blank lines.
+	newItems := make([]bodyItem, len(ordered))
+	for i, item := range ordered {
+		newItems[i] = item.bodyItem
+	}
+	newItems = withoutBlankLines(newItems)
+	for i := 1; i < len(ordered); i++ {
+		if schemaSection(ordered[i]) != schemaSection(ordered[i-1]) {
+			newItems[i].Tokens = append(hclwrite.Tokens{newlineToken()}, newItems[i].Tokens...)
+		}
+	}
+	layout.Items = newItems
+	return layout.BuildTokens(), true, nil
+}
+
+// orderNestedBlockBySchema orders the body of a nested block item and returns
+// the new tokens of the item.
+func orderNestedBlockBySchema(item bodyItem, nested *schema.NestedBlock) (hclwrite.Tokens, bool, error) {
+	if nested == nil || nested.Block == nil {
+		return nil, false, nil
+	}
+	bodyTokens, changed, err := orderBodyBySchema(item.Block.Body(), nested.Block, false)
+	if err != nil || !changed {
+		return nil, false, err
+	}
+	itemTokens, err := replaceItemBody(item, bodyTokens)
+	if err != nil {
+		return nil, false, err
+	}
+	return itemTokens, true, nil
+}
+
+// schemaRank returns the rank of a body item in the schema order.
+func schemaRank(item bodyItem, key string, blockSchema *schema.Block, withMeta bool) (int, int) {
+	if withMeta && item.Name != "dynamic" {
+		for i, name := range leadingMetaArguments {
+			if item.Name == name {
+				return rankLeadingMeta, i
+			}
+		}
+		for i, name := range trailingMetaArguments {
+			if item.Name == name {
+				return rankTrailingMeta, i
+			}
+		}
+	}
+
+	if item.Attribute != nil {
+		attr := blockSchema.Attributes[key]
+		switch {
+		case attr == nil:
+			return rankUnknown, 0
+		case attr.Required:
+			return rankRequired, 0
+		case attr.Optional && attr.Computed:
+			return rankComputedOptional, 0
+		case attr.Optional:
+			return rankOptional, 0
+		default:
+			return rankComputed, 0
+		}
+	}
+
+	if _, ok := blockSchema.BlockTypes[key]; ok {
+		return rankNestedBlock, 0
+	}
+	return rankUnknown, 0
+}
+
+// schemaSection groups ranks into the visual sections of a block body.
+func schemaSection(item rankedItem) int {
+	switch {
+	case item.Rank == rankLeadingMeta, item.Rank == rankTrailingMeta:
+		return item.Rank
+	case item.Block != nil:
+		return rankNestedBlock
+	default:
+		return rankRequired
+	}
+}
@@ -0,0 +1,182 @@
+package sorter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tjun/tfsort/internal/parser"
+	"github.com/tjun/tfsort/internal/schema"
+)
+
+const testProviderSchema = `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/google": {
+      "resource_schemas": {
+        "google_container_cluster": {
+          "version": 1,
+          "block": {
+            "attributes": {
+              "name": {"type": "string", "required": true},
+              "location": {"type": "string", "optional": true, "computed": true},
+              "description": {"type": "string", "optional": true},
+              "network": {"type": "string", "optional": true},
+              "endpoint": {"type": "string", "computed": true}
+            },
+            "block_types": {
+              "node_config": {
+                "nesting_mode": "list",
+                "block": {
+                  "attributes": {
+                    "machine_type": {"type": "string", "optional": true, "computed": true},
+                    "disk_size_gb": {"type": "number", "optional": true}
+                  }
+                }
+              },
+              "addons_config": {
+                "nesting_mode": "list",
+                "block": {}
+              }
+            }
+          }
+        }
+      },
+      "data_source_schemas": {
+        "google_project": {
+          "version": 0,
+          "block": {
+            "attributes": {
+              "project_id": {"type": "string", "optional": true},
+              "name": {"type": "string", "computed": true}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestSchemaOrder(t *testing.T) {
+	schemas, err := schema.Parse(strings.NewReader(testProviderSchema))
+	if err != nil {
+		t.Fatalf("Failed to parse test schema: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		inputHCL string
+		wantHCL  string
+	}{
+		{
+			name: "arguments, nested blocks and meta-arguments",
+			inputHCL: `resource "google_container_cluster" "main" {
+  depends_on = [google_project_service.container]
+  node_config {
+    machine_type = "e2-medium"
+    disk_size_gb = 50
+  }
+  network  = "default"
+  location = "us-central1"
+  # the cluster name
+  name = "main"
+  addons_config {}
+  description = "Main cluster"
+  count       = 1
+}
+`,
+			wantHCL: `resource "google_container_cluster" "main" {
+  count = 1
+
+  # the cluster name
+  name        = "main"
+  description = "Main cluster"
+  network     = "default"
+  location    = "us-central1"
+
+  addons_config {}
+  node_config {
+    disk_size_gb = 50
+    machine_type = "e2-medium"
+  }
+
+  depends_on = [google_project_service.container]
+}
+`,
+		},
+		{
+			name: "unknown attributes keep their position",
+			inputHCL: `resource "google_container_cluster" "main" {
+  network            = "default"
+  custom_unknown     = true
+  name               = "main"
+  another_unknown    = 1
+  description        = "Main cluster"
+}
+`,
+			wantHCL: `resource "google_container_cluster" "main" {
+  name            = "main"
+  custom_unknown  = true
+  description     = "Main cluster"
+  another_unknown = 1
+  network         = "default"
+}
+`,
+		},
+		{
+			name: "data source",
+			inputHCL: `data "google_project" "this" {
+  name       = "x"
+  project_id = "p"
+}
+`,
+			wantHCL: `data "google_project" "this" {
+  project_id = "p"
+  name       = "x"
+}
+`,
+		},
+		{
+			name: "types missing from the schema are untouched",
+			inputHCL: `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  ami           = "ami-123"
+}
+`,
+			wantHCL: `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  ami           = "ami-123"
+}
+`,
+		},
+		{
+			name: "already ordered",
+			inputHCL: `resource "google_container_cluster" "main" {
+  name    = "main"
+  network = "default"
+}
+`,
+			wantHCL: `resource "google_container_cluster" "main" {
+  name    = "main"
+  network = "default"
+}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hclFile, diags := parser.ParseHCL([]byte(tc.inputHCL), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+
+			sortedFile, err := Sort(hclFile, SortOptions{ProviderSchema: schemas})
+			if err != nil {
+				t.Fatalf("Sort() unexpected error = %v", err)
+			}
+			if got := string(sortedFile.Bytes()); got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/tjun/tfsort/internal/schema"
 )
 
 // SortOptions defines the sorting behavior.
@@ -11,6 +12,31 @@ type SortOptions struct {
 	SortBlocks   bool
 	SortTypeName bool
 	SortList     bool
+	// SortAttributes sorts top-level attributes, such as the assignments of a
+	// .tfvars file, by name. Otherwise they keep their original order.
+	SortAttributes bool
+	// SortObjectTypes sorts the attributes of object({...}) type constraints in variable blocks.
+	SortObjectTypes bool
+	// SortLocals orders the keys within each locals block.
+	SortLocals LocalsOrder
+	// MergeLocals combines all top-level locals blocks into the first one.
+	MergeLocals bool
+	// VariableOrder orders variable blocks relative to each other.
+	VariableOrder VariableOrder
+	// VariableGroupHeaders adds a section header comment above each group of
+	// variables when VariableOrder groups them.
+	VariableGroupHeaders bool
+	// SectionHeaders generates section header comments between groups of blocks.
+	SectionHeaders SectionHeaderStyle
+	// Profile selects the dialect whose block order and rules are applied.
+	// Terraform is used when it is nil.
+	Profile *Profile
+	// ProviderSchema, when set, orders the arguments of resource and data blocks
+	// the way the provider schema documents them.
+	ProviderSchema *schema.ProviderSchemas
+	// Explain, when set, records the blocks Sort moves and the lists it sorts
+	// or skips.
+	Explain *Explanation
 }
 
 // Sort parses the input file, sorts it according to options, and returns a new sorted file object.
@@ -20,22 +46,44 @@ func Sort(file *hclwrite.File, options SortOptions) (*hclwrite.File, error) {
 		return file, nil // Return original if input is invalid or empty
 	}
 
-	// Create a new file to build the sorted result
-	newFile := hclwrite.NewEmptyFile()
+	// --- Step 1: Copy Attributes with their comments into a new file ---
+	newFile, trailingAttributes, err := newAttributesFile(file.Body(), options)
+	if err != nil {
+		return nil, err
+	}
 	newBody := newFile.Body()
 
-	// --- Step 1: Copy Attributes (Block sorting shouldn't affect them) ---
-	for name, attr := range file.Body().Attributes() {
-		// Use SetAttributeRaw to preserve the original expression structure as much as possible
-		newBody.SetAttributeRaw(name, attr.Expr().BuildTokens(nil))
+	// --- Step 2: Merge and sort locals blocks ---
+	blocks, err := processLocalsBlocks(file.Body().Blocks(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- Step 3: Move meta-arguments to the top of their blocks and sort block attributes ---
+	blocks, err = hoistMetaArguments(blocks, options.profile())
+	if err != nil {
+		return nil, err
+	}
+	blocks, err = sortBlockAttributes(blocks, options.profile().SortedAttributeBlocks)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- Step 4: Order resource and data arguments by provider schema ---
+	if options.ProviderSchema != nil {
+		blocks, err = orderBlocksBySchema(blocks, options.ProviderSchema)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// --- Step 2: Sort Blocks and add to newBody ---
+	// --- Step 5: Sort Blocks and add to newBody ---
 	if options.SortBlocks {
-		sortAndAddBlocksToBody(file.Body(), newBody, options) // Call the new function
+		if err := sortAndAddBlocksToBody(blocks, newBody, options); err != nil {
+			return nil, err
+		}
 	} else {
 		// If block sorting is disabled, copy blocks in their original order
-		blocks := file.Body().Blocks()
 		for i, block := range blocks {
 			newBody.AppendBlock(block)
 			if i < len(blocks)-1 {
@@ -44,9 +92,28 @@ func Sort(file *hclwrite.File, options SortOptions) (*hclwrite.File, error) {
 		}
 	}
 
-	// --- Step 3: Sort Lists within the new body ---
+	// Attributes that the profile places after the blocks
+	if len(trailingAttributes) > 0 {
+		newFile, err = appendTrailingAttributes(newFile, trailingAttributes)
+		if err != nil {
+			return nil, err
+		}
+		newBody = newFile.Body()
+	}
+
+	// --- Step 6: Sort Lists within the new body ---
 	if options.SortList {
-		SortListValuesInBody(newBody)
+		sortListValuesInScope(newBody, "", "", options.profile().ListScopes, options.Explain)
+	}
+
+	// --- Step 7: Sort object type constraints within variable blocks ---
+	if options.SortObjectTypes {
+		SortObjectTypesInBody(newBody)
+	}
+
+	// --- Step 8: Sort the keys of object values the profile lists ---
+	if paths := options.profile().SortedObjectAttributes; len(paths) > 0 {
+		sortObjectAttributesInBody(newBody, "", paths)
 	}
 
 	// Check if anything actually changed compared to original file bytes
@@ -0,0 +1,221 @@
+package sorter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// SplitLayout selects the file each top-level block of a module belongs to.
+type SplitLayout string
+
+const (
+	// SplitLayoutConventional uses versions.tf, providers.tf, variables.tf,
+	// outputs.tf, locals.tf and data.tf, and main.tf for everything else.
+	SplitLayoutConventional SplitLayout = "conventional"
+	// SplitLayoutProvider additionally moves resources to a file named after
+	// their provider, such as aws.tf.
+	SplitLayoutProvider SplitLayout = "provider"
+	// SplitLayoutService additionally moves resources to a file named after
+	// their service, such as iam.tf for aws_iam_role.
+	SplitLayoutService SplitLayout = "service"
+)
+
+// defaultSplitFile receives the blocks that no other file of a layout claims.
+const defaultSplitFile = "main.tf"
+
+// conventionalFiles maps block types to the file they belong to in every layout.
+var conventionalFiles = map[string]string{
+	"terraform": "versions.tf",
+	"provider":  "providers.tf",
+	"variable":  "variables.tf",
+	"output":    "outputs.tf",
+	"locals":    "locals.tf",
+	"data":      "data.tf",
+}
+
+// ParseSplitLayout converts a command-line value into a SplitLayout. An empty
+// value selects the conventional layout.
+func ParseSplitLayout(value string) (SplitLayout, error) {
+	switch layout := SplitLayout(value); layout {
+	case "":
+		return SplitLayoutConventional, nil
+	case SplitLayoutConventional, SplitLayoutProvider, SplitLayoutService:
+		return layout, nil
+	}
+	return "", fmt.Errorf("invalid split layout %q (want %q, %q or %q)", value, SplitLayoutConventional, SplitLayoutProvider, SplitLayoutService)
+}
+
+// fileFor returns the name of the file block belongs to.
+func (l SplitLayout) fileFor(block *hclwrite.Block) string {
+	if labels := block.Labels(); block.Type() == "resource" && len(labels) > 0 && l != SplitLayoutConventional {
+		return l.resourcePrefix(labels[0]) + ".tf"
+	}
+	if name, ok := conventionalFiles[block.Type()]; ok {
+		return name
+	}
+	return defaultSplitFile
+}
+
+// resourcePrefix returns the part of a resource type that names its file. The
+// service of aws_iam_role is iam; types without a service part, such as
+// random_id, fall back to the provider.
+func (l SplitLayout) resourcePrefix(resourceType string) string {
+	parts := strings.Split(resourceType, "_")
+	if l == SplitLayoutService && len(parts) > 2 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// SplitMove records a block that moves from one file to another.
+type SplitMove struct {
+	Address string // Block type and labels joined with dots, such as resource.aws_s3_bucket.logs
+	From    string
+	To      string
+}
+
+// SplitResult holds the files of a module after a split.
+type SplitResult struct {
+	// Files holds the content of every file of the module by name, including
+	// the files created by the split. Files left without content are nil.
+	Files map[string][]byte
+	// Moves lists the moved blocks in the order of their source files.
+	Moves []SplitMove
+}
+
+// SplitModule moves the top-level blocks of the files of a module, given by
+// name, to the files the layout assigns them to. Comments above a block move
+// with it, and comments after the last block of a file stay at its end. Every
+// resulting file is sorted with options.
+func SplitModule(files map[string][]byte, layout SplitLayout, options SortOptions) (*SplitResult, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &SplitResult{Files: make(map[string][]byte)}
+	layouts := make(map[string]bodyLayout)
+	changed := make(map[string]bool)
+	incoming := make(map[string][]bodyItem)
+	var targets []string
+
+	for _, name := range names {
+		file, diags := hclwrite.ParseConfig(files[name], name, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %v", name, diags)
+		}
+
+		fileLayout := splitFileItems(file.Body())
+		var kept []bodyItem
+		for _, item := range fileLayout.Items {
+			if item.Block == nil {
+				kept = append(kept, item)
+				continue
+			}
+			target := layout.fileFor(item.Block)
+			if target == name {
+				kept = append(kept, item)
+				continue
+			}
+			result.Moves = append(result.Moves, SplitMove{Address: blockAddress(item.Block), From: name, To: target})
+			if _, exists := incoming[target]; !exists {
+				targets = append(targets, target)
+			}
+			incoming[target] = append(incoming[target], item)
+			changed[name] = true
+			changed[target] = true
+		}
+		fileLayout.Items = kept
+		layouts[name] = fileLayout
+	}
+
+	for _, target := range targets {
+		if _, exists := layouts[target]; !exists {
+			names = append(names, target)
+		}
+	}
+
+	for _, name := range names {
+		fileLayout := layouts[name]
+		if !changed[name] {
+			content, err := sortSplitFile(files[name], name, options)
+			if err != nil {
+				return nil, err
+			}
+			result.Files[name] = withTrailingComments(content, fileLayout.Trailing)
+			continue
+		}
+
+		// Blank lines no longer separate anything once blocks have moved, and
+		// sorting drops comments that are detached from their block by one.
+		items := withoutBlankLines(append(fileLayout.Items, incoming[name]...))
+		if len(items) == 0 {
+			// Comments after the last block stay behind in a file of their own.
+			result.Files[name] = withTrailingComments(nil, fileLayout.Trailing)
+			continue
+		}
+		fileLayout.Items = items
+		content, err := sortSplitFile(fileLayout.BuildTokens().Bytes(), name, options)
+		if err != nil {
+			return nil, err
+		}
+		result.Files[name] = withTrailingComments(content, fileLayout.Trailing)
+	}
+	return result, nil
+}
+
+// sortSplitFile parses and sorts the content of a file produced by a split.
+func sortSplitFile(content []byte, name string, options SortOptions) ([]byte, error) {
+	file, diags := hclwrite.ParseConfig(content, name, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to rebuild %s: %v", name, diags)
+	}
+	sorted, err := Sort(file, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sort %s: %w", name, err)
+	}
+	return sorted.Bytes(), nil
+}
+
+// withTrailingComments appends trailing, the comments and blank lines after
+// the last item of a file, to content, the file after sorting, which drops
+// them. They are separated from the last item by a blank line. Trailing tokens
+// without a comment add nothing.
+func withTrailingComments(content []byte, trailing hclwrite.Tokens) []byte {
+	if !containsComment(trailing) {
+		return content
+	}
+	var b bytes.Buffer
+	b.Write(content)
+	if len(content) > 0 {
+		if !bytes.HasSuffix(content, []byte("\n")) {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.Write(bytes.TrimRight(removeLeadingNewlines(trailing).Bytes(), "\n"))
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// blockAddress joins the type and labels of block with dots.
+func blockAddress(block *hclwrite.Block) string {
+	return strings.Join(append([]string{block.Type()}, block.Labels()...), ".")
+}
+
+// containsComment reports whether tokens contain a comment.
+func containsComment(tokens hclwrite.Tokens) bool {
+	for _, tok := range tokens {
+		if tok.Type == hclsyntax.TokenComment {
+			return true
+		}
+	}
+	return false
+}
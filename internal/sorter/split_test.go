@@ -0,0 +1,195 @@
+package sorter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitModule(t *testing.T) {
+	testCases := []struct {
+		name      string
+		files     map[string]string
+		layout    SplitLayout
+		wantFiles map[string]string // An empty string means the file is removed
+		wantMoves []SplitMove
+	}{
+		{
+			name: "conventional layout",
+			files: map[string]string{
+				"main.tf": `# Pinned versions
+terraform {
+  required_version = ">= 1.5"
+}
+
+variable "name" {}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = var.name
+}
+
+# Bucket name
+
+output "bucket" {
+  value = aws_s3_bucket.logs.id
+}
+`,
+				"variables.tf": "variable \"env\" {}\n",
+			},
+			layout: SplitLayoutConventional,
+			wantFiles: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"logs\" {\n  bucket = var.name\n}\n",
+				"outputs.tf":   "# Bucket name\noutput \"bucket\" {\n  value = aws_s3_bucket.logs.id\n}\n",
+				"variables.tf": "variable \"env\" {}\n\nvariable \"name\" {}\n",
+				"versions.tf":  "# Pinned versions\nterraform {\n  required_version = \">= 1.5\"\n}\n",
+			},
+			wantMoves: []SplitMove{
+				{Address: "terraform", From: "main.tf", To: "versions.tf"},
+				{Address: "variable.name", From: "main.tf", To: "variables.tf"},
+				{Address: "output.bucket", From: "main.tf", To: "outputs.tf"},
+			},
+		},
+		{
+			name: "service layout removes emptied files",
+			files: map[string]string{
+				"main.tf": `resource "aws_iam_role" "b" {}
+
+resource "aws_s3_bucket" "logs" {}
+
+resource "aws_iam_role" "a" {}
+
+resource "aws_instance" "web" {}
+`,
+			},
+			layout: SplitLayoutService,
+			wantFiles: map[string]string{
+				"aws.tf":  "resource \"aws_instance\" \"web\" {}\n",
+				"iam.tf":  "resource \"aws_iam_role\" \"a\" {}\n\nresource \"aws_iam_role\" \"b\" {}\n",
+				"main.tf": "",
+				"s3.tf":   "resource \"aws_s3_bucket\" \"logs\" {}\n",
+			},
+			wantMoves: []SplitMove{
+				{Address: "resource.aws_iam_role.b", From: "main.tf", To: "iam.tf"},
+				{Address: "resource.aws_s3_bucket.logs", From: "main.tf", To: "s3.tf"},
+				{Address: "resource.aws_iam_role.a", From: "main.tf", To: "iam.tf"},
+				{Address: "resource.aws_instance.web", From: "main.tf", To: "aws.tf"},
+			},
+		},
+		{
+			name: "provider layout collects resources from several files",
+			files: map[string]string{
+				"network.tf": "resource \"aws_vpc\" \"main\" {}\n",
+				"random.tf":  "resource \"random_id\" \"suffix\" {}\n",
+				"storage.tf": "resource \"aws_s3_bucket\" \"logs\" {}\n\nresource \"random_pet\" \"name\" {}\n",
+			},
+			layout: SplitLayoutProvider,
+			wantFiles: map[string]string{
+				"aws.tf":     "resource \"aws_s3_bucket\" \"logs\" {}\n\nresource \"aws_vpc\" \"main\" {}\n",
+				"network.tf": "",
+				"random.tf":  "resource \"random_id\" \"suffix\" {}\n\nresource \"random_pet\" \"name\" {}\n",
+				"storage.tf": "",
+			},
+			wantMoves: []SplitMove{
+				{Address: "resource.aws_vpc.main", From: "network.tf", To: "aws.tf"},
+				{Address: "resource.aws_s3_bucket.logs", From: "storage.tf", To: "aws.tf"},
+				{Address: "resource.random_pet.name", From: "storage.tf", To: "random.tf"},
+			},
+		},
+		{
+			name: "trailing comments stay behind",
+			files: map[string]string{
+				"main.tf": "variable \"a\" {}\n\n# TODO: add outputs\n",
+			},
+			layout: SplitLayoutConventional,
+			wantFiles: map[string]string{
+				"main.tf":      "# TODO: add outputs\n",
+				"variables.tf": "variable \"a\" {}\n",
+			},
+			wantMoves: []SplitMove{
+				{Address: "variable.a", From: "main.tf", To: "variables.tf"},
+			},
+		},
+		{
+			name: "trailing comments stay with remaining blocks",
+			files: map[string]string{
+				"main.tf":      "variable \"a\" {}\n\nresource \"aws_s3_bucket\" \"logs\" {}\n\n# TODO: add outputs\n",
+				"variables.tf": "variable \"b\" {}\n# End of variables\n",
+			},
+			layout: SplitLayoutConventional,
+			wantFiles: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"logs\" {}\n\n# TODO: add outputs\n",
+				"variables.tf": "variable \"b\" {}\n\nvariable \"a\" {}\n\n# End of variables\n",
+			},
+			wantMoves: []SplitMove{
+				{Address: "variable.a", From: "main.tf", To: "variables.tf"},
+			},
+		},
+		{
+			name: "files that keep their blocks are still sorted",
+			files: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"logs\" {}\n",
+				"variables.tf": "variable \"b\" {}\nvariable \"a\" {}\n",
+			},
+			layout: SplitLayoutConventional,
+			wantFiles: map[string]string{
+				"main.tf":      "resource \"aws_s3_bucket\" \"logs\" {}\n",
+				"variables.tf": "variable \"b\" {}\n\nvariable \"a\" {}\n",
+			},
+		},
+	}
+
+	options := SortOptions{SortBlocks: true, SortTypeName: true, SortList: true}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := make(map[string][]byte)
+			for name, content := range tc.files {
+				files[name] = []byte(content)
+			}
+			result, err := SplitModule(files, tc.layout, options)
+			if err != nil {
+				t.Fatalf("SplitModule() unexpected error = %v", err)
+			}
+
+			gotFiles := make(map[string]string)
+			for name, content := range result.Files {
+				gotFiles[name] = string(content)
+			}
+			if !reflect.DeepEqual(gotFiles, tc.wantFiles) {
+				t.Errorf("SplitModule() files = %#v, want %#v", gotFiles, tc.wantFiles)
+			}
+			for name, content := range tc.wantFiles {
+				if content == "" && result.Files[name] != nil {
+					t.Errorf("SplitModule() file %s = %q, want it removed", name, result.Files[name])
+				}
+			}
+			if !reflect.DeepEqual(result.Moves, tc.wantMoves) {
+				t.Errorf("SplitModule() moves = %v, want %v", result.Moves, tc.wantMoves)
+			}
+		})
+	}
+}
+
+func TestParseSplitLayout(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    SplitLayout
+		wantErr bool
+	}{
+		{value: "", want: SplitLayoutConventional},
+		{value: "conventional", want: SplitLayoutConventional},
+		{value: "provider", want: SplitLayoutProvider},
+		{value: "service", want: SplitLayoutService},
+		{value: "by-size", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseSplitLayout(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseSplitLayout(%q) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseSplitLayout(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
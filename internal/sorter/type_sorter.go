@@ -0,0 +1,339 @@
+package sorter
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// objectTypeAttribute is a single `name = type` entry of an object({...}) type constructor.
+type objectTypeAttribute struct {
+	LeadingComments hclwrite.Tokens
+	Tokens          hclwrite.Tokens // Name, type expression, separator and any trailing comment
+	Name            []byte
+}
+
+// SortObjectTypesInBody sorts the attributes of object({...}) type constructors
+// found in the `type` argument of every variable block in the body.
+func SortObjectTypesInBody(body *hclwrite.Body) {
+	if body == nil {
+		return
+	}
+
+	for _, block := range body.Blocks() {
+		if block.Type() != "variable" {
+			continue
+		}
+		attr := block.Body().GetAttribute("type")
+		if attr == nil {
+			continue
+		}
+		newExprTokens, wasModified := sortObjectTypesInExpression(attr.Expr().BuildTokens(nil))
+		if wasModified {
+			block.Body().SetAttributeRaw("type", newExprTokens)
+		}
+	}
+}
+
+// sortObjectTypesInExpression searches the tokens of a type expression for object({...})
+// constructors and sorts their attributes by name. Wrappers such as list(), map(),
+// set(), tuple() and optional() are walked transparently because every object()
+// call found in the token stream is handled, including nested ones.
+// Returns the modified tokens and true if any attributes were reordered.
+func sortObjectTypesInExpression(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	anySorted := false
+	var result hclwrite.Tokens
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		result = append(result, tok)
+
+		if !isObjectTypeCall(tokens, i) {
+			continue
+		}
+
+		// tokens[i] is "object", tokens[i+1] is "(", tokens[i+2] is "{"
+		closeIdx := findMatchingClose(tokens, i+2)
+		if closeIdx < 0 {
+			continue
+		}
+
+		sortedInner, sorted := sortObjectTypeAttributes(tokens[i+3 : closeIdx])
+		if sorted {
+			anySorted = true
+		}
+		result = append(result, tokens[i+1], tokens[i+2])
+		result = append(result, sortedInner...)
+		result = append(result, tokens[closeIdx])
+		i = closeIdx
+	}
+
+	return result, anySorted
+}
+
+// isObjectTypeCall reports whether tokens[i:] starts with `object({`.
+func isObjectTypeCall(tokens hclwrite.Tokens, i int) bool {
+	return i+2 < len(tokens) &&
+		tokens[i].Type == hclsyntax.TokenIdent && string(tokens[i].Bytes) == "object" &&
+		tokens[i+1].Type == hclsyntax.TokenOParen &&
+		tokens[i+2].Type == hclsyntax.TokenOBrace
+}
+
+// findMatchingClose returns the index of the token closing the bracket, brace or
+// parenthesis opened at tokens[openIdx], or -1 if it is unbalanced.
+func findMatchingClose(tokens hclwrite.Tokens, openIdx int) int {
+	level := 0
+	for j := openIdx; j < len(tokens); j++ {
+		switch tokens[j].Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen:
+			level++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen:
+			level--
+			if level == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// sortObjectTypeAttributes sorts the entries between the braces of an object({...})
+// type constructor. Nested type constraints inside each entry are sorted first.
+func sortObjectTypeAttributes(innerTokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	return sortObjectEntries(innerTokens, sortObjectTypesInExpression)
+}
+
+// sortObjectEntries sorts the `name = value` entries between the braces of an
+// object by name. If nested is not nil, it is applied to the tokens of every
+// entry first. Objects with keys that are not plain names or strings are left
+// unchanged.
+func sortObjectEntries(innerTokens hclwrite.Tokens, nested func(hclwrite.Tokens) (hclwrite.Tokens, bool)) (hclwrite.Tokens, bool) {
+	// Keep a comment on the same line as the opening brace attached to the brace.
+	var braceComments hclwrite.Tokens
+	for len(innerTokens) > 0 && innerTokens[0].Type == hclsyntax.TokenComment {
+		braceComments = append(braceComments, innerTokens[0])
+		innerTokens = innerTokens[1:]
+	}
+
+	// The newline after the opening brace belongs to the brace, not to the first entry.
+	entries, trailing, ok := extractObjectTypeAttributes(removeLeadingNewlines(innerTokens))
+	if !ok || len(entries) == 0 {
+		return append(braceComments, innerTokens...), false
+	}
+
+	anySorted := false
+	for i := range entries {
+		if nested == nil {
+			break
+		}
+		sortedTokens, sorted := nested(entries[i].Tokens)
+		if sorted {
+			entries[i].Tokens = sortedTokens
+			anySorted = true
+		}
+	}
+
+	originalOrder := make([]objectTypeAttribute, len(entries))
+	copy(originalOrder, entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Name, entries[j].Name) < 0
+	})
+
+	reordered := false
+	for i := range entries {
+		if !bytes.Equal(originalOrder[i].Name, entries[i].Name) {
+			reordered = true
+			break
+		}
+	}
+	if !reordered && !anySorted {
+		return append(braceComments, innerTokens...), false
+	}
+	if !reordered {
+		entries = originalOrder
+	} else {
+		// A comma separates the entry at its position from the next one, so
+		// it stays at its position rather than moving with the entry.
+		for i := range entries {
+			entries[i].Tokens = withSeparator(entries[i].Tokens, hasSeparator(originalOrder[i].Tokens))
+		}
+	}
+
+	var rebuilt hclwrite.Tokens
+	if isMultiLineTokens(innerTokens) || len(braceComments) > 0 {
+		rebuilt = rebuildMultiLineObjectType(entries, trailing)
+	} else {
+		rebuilt = rebuildSingleLineObjectType(entries)
+	}
+	return append(braceComments, rebuilt...), true
+}
+
+// extractObjectTypeAttributes splits the inner tokens of an object type constructor
+// into its entries. Comments on their own lines are attached to the entry that
+// follows them; comments that follow the last entry are returned as trailing tokens.
+func extractObjectTypeAttributes(innerTokens hclwrite.Tokens) ([]objectTypeAttribute, hclwrite.Tokens, bool) {
+	var entries []objectTypeAttribute
+	var leading, current hclwrite.Tokens
+	level := 0
+	sawComma := false
+
+	finish := func() bool {
+		name := objectTypeAttributeName(current)
+		if name == nil {
+			return false
+		}
+		entries = append(entries, objectTypeAttribute{LeadingComments: leading, Tokens: current, Name: name})
+		leading, current = nil, nil
+		sawComma = false
+		return true
+	}
+
+	for _, tok := range innerTokens {
+		isTrivia := tok.Type == hclsyntax.TokenNewline || tok.Type == hclsyntax.TokenComment || tok.Type == hclsyntax.TokenTabs
+
+		if level == 0 && len(current) == 0 {
+			if isTrivia {
+				leading = append(leading, tok)
+				continue
+			}
+		}
+
+		// A new entry starting on the same line as a comma-terminated one.
+		if level == 0 && sawComma && !isTrivia {
+			if !finish() {
+				return nil, nil, false
+			}
+		}
+
+		switch tok.Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen:
+			level++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen:
+			level--
+		}
+		current = append(current, tok)
+
+		if level != 0 {
+			continue
+		}
+		switch {
+		case tok.Type == hclsyntax.TokenComma:
+			sawComma = true
+		case tok.Type == hclsyntax.TokenNewline,
+			tok.Type == hclsyntax.TokenComment && bytes.HasSuffix(tok.Bytes, []byte("\n")):
+			if !finish() {
+				return nil, nil, false
+			}
+		}
+	}
+
+	if len(current) > 0 {
+		if !finish() {
+			return nil, nil, false
+		}
+	}
+
+	return entries, leading, true
+}
+
+// objectTypeAttributeName returns the attribute name at the start of an entry,
+// or nil if the entry does not look like `name = type`.
+func objectTypeAttributeName(tokens hclwrite.Tokens) []byte {
+	if len(tokens) >= 2 && tokens[0].Type == hclsyntax.TokenIdent &&
+		(tokens[1].Type == hclsyntax.TokenEqual || tokens[1].Type == hclsyntax.TokenColon) {
+		return tokens[0].Bytes
+	}
+	if len(tokens) >= 4 && tokens[0].Type == hclsyntax.TokenOQuote &&
+		tokens[1].Type == hclsyntax.TokenQuotedLit && tokens[2].Type == hclsyntax.TokenCQuote {
+		return tokens[1].Bytes
+	}
+	return nil
+}
+
+// lastEntryToken returns the index of the last token of an entry that is not a
+// comment, a newline or tabs, or -1 if there is none.
+func lastEntryToken(tokens hclwrite.Tokens) int {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch tokens[i].Type {
+		case hclsyntax.TokenNewline, hclsyntax.TokenComment, hclsyntax.TokenTabs:
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// hasSeparator reports whether the tokens of an entry end with a comma before
+// any trailing comment.
+func hasSeparator(tokens hclwrite.Tokens) bool {
+	i := lastEntryToken(tokens)
+	return i >= 0 && tokens[i].Type == hclsyntax.TokenComma
+}
+
+// withSeparator returns the tokens of an entry with a comma before any trailing
+// comment if comma is set, and without one otherwise.
+func withSeparator(tokens hclwrite.Tokens, comma bool) hclwrite.Tokens {
+	if hasSeparator(tokens) == comma {
+		return tokens
+	}
+	i := lastEntryToken(tokens)
+	if i < 0 {
+		return tokens
+	}
+	result := make(hclwrite.Tokens, 0, len(tokens)+1)
+	if comma {
+		result = append(result, tokens[:i+1]...)
+		result = append(result, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+	} else {
+		result = append(result, tokens[:i]...)
+	}
+	return append(result, tokens[i+1:]...)
+}
+
+// rebuildMultiLineObjectType lays out sorted entries one per line.
+func rebuildMultiLineObjectType(entries []objectTypeAttribute, trailing hclwrite.Tokens) hclwrite.Tokens {
+	rebuilt := hclwrite.Tokens{&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}}
+
+	for _, entry := range entries {
+		rebuilt = append(rebuilt, entry.LeadingComments...)
+		rebuilt = append(rebuilt, entry.Tokens...)
+		rebuilt = append(rebuilt, ensureTrailingNewline(rebuilt)...)
+	}
+
+	rebuilt = append(rebuilt, trailing...)
+	rebuilt = append(rebuilt, ensureTrailingNewline(rebuilt)...)
+	return rebuilt
+}
+
+// rebuildSingleLineObjectType joins sorted entries with commas on a single line.
+// The last entry keeps its trailing comma, if it has one.
+func rebuildSingleLineObjectType(entries []objectTypeAttribute) hclwrite.Tokens {
+	var rebuilt hclwrite.Tokens
+	for i, entry := range entries {
+		rebuilt = append(rebuilt, entry.LeadingComments...)
+		if i == len(entries)-1 {
+			rebuilt = append(rebuilt, entry.Tokens...)
+			break
+		}
+		valueTokens := entry.Tokens
+		if endsWithComma(valueTokens) {
+			valueTokens = valueTokens[:len(valueTokens)-1]
+		}
+		rebuilt = append(rebuilt, valueTokens...)
+		rebuilt = append(rebuilt, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+	}
+	return rebuilt
+}
+
+// isMultiLineTokens checks if the tokens span more than one line.
+func isMultiLineTokens(tokens hclwrite.Tokens) bool {
+	for _, tok := range tokens {
+		if tok.Type == hclsyntax.TokenNewline ||
+			(tok.Type == hclsyntax.TokenComment && bytes.HasSuffix(tok.Bytes, []byte("\n"))) {
+			return true
+		}
+	}
+	return false
+}
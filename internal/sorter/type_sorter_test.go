@@ -0,0 +1,196 @@
+package sorter
+
+import (
+	"testing"
+
+	"github.com/tjun/tfsort/internal/parser"
+)
+
+func TestObjectTypeSort(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputHCL    string
+		wantHCL     string
+		sortOptions SortOptions
+	}{
+		{
+			name: "single-line object type",
+			inputHCL: `variable "a" {
+  type = object({ zone = string, name = string, count = number })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({ count = number, name = string, zone = string })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "single-line object type keeps its trailing comma",
+			inputHCL: `variable "a" {
+  type = object({ zone = string, name = string, })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({ name = string, zone = string, })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "multi-line object type with comments",
+			inputHCL: `variable "a" {
+  type = object({
+    # where it runs
+    zone = string
+    name = string # display name
+    count = optional(number, 1)
+  })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({
+    count = optional(number, 1)
+    name  = string # display name
+    # where it runs
+    zone = string
+  })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "multi-line object type with entries on one line",
+			inputHCL: `variable "a" {
+  type = object({
+    b = string, a = string
+  })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({
+    a = string,
+    b = string
+  })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "multi-line object type with trailing commas",
+			inputHCL: `variable "a" {
+  type = object({
+    b = string, # second
+    a = string
+  })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({
+    a = string,
+    b = string # second
+  })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "nested objects in wrappers",
+			inputHCL: `variable "a" {
+  type = list(object({
+    zone = string
+    settings = optional(object({
+      tier = string
+      disk = map(object({ size = number, kind = string }))
+    }))
+  }))
+}
+`,
+			wantHCL: `variable "a" {
+  type = list(object({
+    settings = optional(object({
+      disk = map(object({ kind = string, size = number }))
+      tier = string
+    }))
+    zone = string
+  }))
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "trailing commas and closing comment",
+			inputHCL: `variable "a" {
+  type = object({
+    b = string,
+    a = string,
+    # end of attributes
+  })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({
+    a = string,
+    b = string,
+    # end of attributes
+  })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "already sorted",
+			inputHCL: `variable "a" {
+  type = map(object({ a = string, b = string }))
+}
+`,
+			wantHCL: `variable "a" {
+  type = map(object({ a = string, b = string }))
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "only variable type arguments are sorted",
+			inputHCL: `locals {
+  type = object({ b = string, a = string })
+}
+`,
+			wantHCL: `locals {
+  type = object({ b = string, a = string })
+}
+`,
+			sortOptions: SortOptions{SortObjectTypes: true},
+		},
+		{
+			name: "disabled",
+			inputHCL: `variable "a" {
+  type = object({ b = string, a = string })
+}
+`,
+			wantHCL: `variable "a" {
+  type = object({ b = string, a = string })
+}
+`,
+			sortOptions: SortOptions{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hclFile, diags := parser.ParseHCL([]byte(tc.inputHCL), "test.tf")
+			if diags.HasErrors() {
+				t.Fatalf("Failed to parse input HCL: %v", diags)
+			}
+
+			sortedFile, err := Sort(hclFile, tc.sortOptions)
+			if err != nil {
+				t.Fatalf("Sort() unexpected error = %v", err)
+			}
+
+			if got := string(sortedFile.Bytes()); got != tc.wantHCL {
+				t.Errorf("Sort() output mismatch\nGot:\n%s\nWant:\n%s", got, tc.wantHCL)
+			}
+		})
+	}
+}